@@ -0,0 +1,473 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// adminUsersMaxLimit is the maximum page size of the admin users list.
+const adminUsersMaxLimit = 100
+
+// AdminController struct to describe user management and audit log handlers for admins.
+type AdminController struct {
+	*Dependencies
+}
+
+// GetUsers method to list users for admins.
+// @Description List users with filters and pagination.
+// @Summary list users
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number, starts from 1"
+// @Param limit query int false "Page size, max 100"
+// @Param role query string false "User role"
+// @Param status query int false "User status (0 == blocked, 1 == active)"
+// @Param email query string false "Part of email to search"
+// @Param created_from query string false "Created at or after (RFC 3339)"
+// @Param created_to query string false "Created before (RFC 3339)"
+// @Param deleted query bool false "List deleted users instead"
+// @Success 200 {object} models.Response{page=int,limit=int,total=int,count=int,users=[]models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users [get]
+func (a *AdminController) GetUsers(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.UserManageCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get pagination from query.
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeBadRequest, "limit must be at least 1")
+	}
+	if limit > adminUsersMaxLimit {
+		limit = adminUsersMaxLimit
+	}
+
+	filter := repository.UserFilter{
+		Role:    c.Query("role"),
+		Email:   c.Query("email"),
+		Deleted: c.QueryBool("deleted"),
+		Offset:  (page - 1) * limit,
+		Limit:   limit,
+	}
+
+	// Apply filters from query.
+	if status := c.Query("status"); status != "" {
+		value, err := strconv.Atoi(status)
+		if err != nil || (value != 0 && value != 1) {
+			// Return status 400 and error message.
+			return apperror.BadRequest(apperror.CodeBadRequest, "status must be 0 or 1")
+		}
+		filter.Status = &value
+	}
+	for param, field := range map[string]*time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+	} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				// Return status 400 and error message.
+				return apperror.BadRequest(apperror.CodeBadRequest, param+" must be a RFC 3339 time")
+			}
+			*field = t
+		}
+	}
+
+	// Get requested page of users and count all matching ones.
+	users, total, err := a.Users.List(c.UserContext(), filter)
+	if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Delete password hash field from JSON view.
+	for i := range users {
+		users[i].PasswordHash = ""
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+		"count":   len(users),
+		"users":   users,
+	})
+}
+
+// GetUser method to get one user by ID for admins.
+// @Description Get one user by ID.
+// @Summary get user by ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.Response{user=models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id} [get]
+func (a *AdminController) GetUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.UserManageCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get user by ID from path.
+	user, err := a.pathUser(c, a.Users.GetByID)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+	})
+}
+
+// UpdateUser method to update names, role or status of a user for admins.
+// @Description Update names, role or status of a user. Changing role or status ends the user's sessions.
+// @Summary update user by ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param firstname body string false "First name"
+// @Param lastname body string false "Last name"
+// @Param user_role body string false "User role"
+// @Param user_status body int false "User status (0 == blocked, 1 == active)"
+// @Success 200 {object} models.Response{user=models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id} [patch]
+func (a *AdminController) UpdateUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	claims, err := a.checkCredential(c, repository.UserManageCredential)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new update user struct.
+	update := &models.UpdateUser{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(update); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate update fields.
+	if err := utils.NewValidator().Struct(update); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Get user by ID from path.
+	user, err := a.pathUser(c, a.Users.GetByID)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Set changed fields, keep previous role and status for the audit log.
+	previousRole, previousStatus := user.UserRole, user.UserStatus
+	endSessions := false
+	if update.FirstName != nil {
+		user.FirstName = *update.FirstName
+	}
+	if update.LastName != nil {
+		user.LastName = *update.LastName
+	}
+	if update.UserRole != nil && *update.UserRole != user.UserRole {
+		// Checking role from update data.
+		role, err := utils.VerifyRole(*update.UserRole)
+		if err != nil {
+			// Return status 400 and error message.
+			return apperror.BadRequest(apperror.CodeInvalidRole, err.Error())
+		}
+		user.UserRole = role
+		endSessions = true
+	}
+	if update.UserStatus != nil && *update.UserStatus != user.UserStatus {
+		user.UserStatus = *update.UserStatus
+		endSessions = true
+	}
+
+	// Admins can't lock themselves out.
+	if endSessions && user.ID == claims.UserID {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeSelfModification, "you can't change your own role or status")
+	}
+
+	// Save changed fields.
+	user.UpdatedAt = time.Now()
+	if err := a.Users.Update(c.UserContext(), user); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Audit role and status changes.
+	if user.UserRole != previousRole {
+		a.audit(c, &models.AuditEvent{
+			ActorID:  &claims.UserID,
+			TargetID: &user.ID,
+			Action:   "user.role_change",
+			Outcome:  models.AuditOutcomeSuccess,
+			Metadata: models.AuditMetadata{"from": previousRole, "to": user.UserRole},
+		})
+	}
+	if user.UserStatus != previousStatus {
+		a.audit(c, &models.AuditEvent{
+			ActorID:  &claims.UserID,
+			TargetID: &user.ID,
+			Action:   "user.status_change",
+			Outcome:  models.AuditOutcomeSuccess,
+			Metadata: models.AuditMetadata{"from": strconv.Itoa(previousStatus), "to": strconv.Itoa(user.UserStatus)},
+		})
+	}
+
+	// End sessions, so the user can't renew tokens with the previous role or status.
+	// Admin credentials are checked against the stored role and status at once.
+	if endSessions {
+		if err := a.revokeSessions(c.UserContext(), user.ID); err != nil {
+			// Return status 500 and Redis error.
+			return apperror.Internal(err)
+		}
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+	})
+}
+
+// SignOutUser method to force sign out of a user for admins.
+// @Description Delete refresh token of a user from Redis, so the user has to sign in again.
+// @Summary force sign out of user
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id}/signout [post]
+func (a *AdminController) SignOutUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.UserManageCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get user by ID from path.
+	user, err := a.pathUser(c, a.Users.GetByID)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Delete refresh token from Redis.
+	if err := a.revokeSessions(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and Redis error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteUser method to soft delete a user for admins.
+// @Description Soft delete a user and end the user's sessions, the user can be restored later.
+// @Summary delete user by ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id} [delete]
+func (a *AdminController) DeleteUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	claims, err := a.checkCredential(c, repository.UserManageCredential)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get user by ID from path.
+	user, err := a.pathUser(c, a.Users.GetByID)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Admins can't delete themselves.
+	if user.ID == claims.UserID {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeSelfModification, "you can't delete your own account")
+	}
+
+	// Soft delete user.
+	if err := a.Users.Delete(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Delete refresh token from Redis.
+	if err := a.revokeSessions(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and Redis error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RestoreUser method to restore a soft deleted user for admins.
+// @Description Restore a soft deleted user.
+// @Summary restore user by ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.Response{user=models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id}/restore [post]
+func (a *AdminController) RestoreUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.UserManageCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get deleted user by ID from path.
+	user, err := a.pathUser(c, a.Users.GetByIDWithDeleted)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+	if !user.DeletedAt.Valid {
+		// Return status 404 and error message.
+		return apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	}
+
+	// Check the email was not taken while the user was deleted.
+	if a.emailTaken(c.UserContext(), user.Email, user.ID) {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeEmailTaken, "Email address is already registered")
+	}
+
+	// Restore user.
+	user.DeletedAt = gorm.DeletedAt{}
+	if err := a.Users.Update(c.UserContext(), user); errors.Is(err, repository.ErrDuplicate) {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeEmailTaken, "Email address is already registered")
+	} else if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+	})
+}
+
+// checkCredential method to get claims of the current user, if the token carries the credential
+// and the user still has it. Role and status are loaded from the database, so a blocked, deleted or
+// demoted admin loses access before the token expires.
+func (d *Dependencies) checkCredential(c *fiber.Ctx, credential string) (*utils.TokenMetadata, error) {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	// Checking, if the token carries the credential.
+	if !claims.Credentials[credential] {
+		return nil, apperror.Forbidden(apperror.CodePermissionDenied, "permission denied, check credentials of your token")
+	}
+
+	// Get the current user by ID.
+	user, err := d.Users.GetByID(c.UserContext(), claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperror.Forbidden(apperror.CodePermissionDenied, "permission denied, check credentials of your token")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	// Check if the user account was deactivated.
+	if user.UserStatus != 1 {
+		return nil, apperror.Forbidden(apperror.CodeAccountBlocked, "The user account is blocked")
+	}
+
+	// Checking, if the current role still grants the credential.
+	credentials, err := utils.GetCredentialsByRole(user.UserRole)
+	if err != nil || !slices.Contains(credentials, credential) {
+		return nil, apperror.Forbidden(apperror.CodePermissionDenied, "permission denied, check credentials of your token")
+	}
+
+	return claims, nil
+}
+
+// pathUser method to get user by ID from path with the given getter, like Users.GetByID.
+func (d *Dependencies) pathUser(c *fiber.Ctx, get func(ctx context.Context, id uuid.UUID) (*models.User, error)) (*models.User, error) {
+	// Parse user ID from path.
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest(apperror.CodeInvalidID, "user ID is not valid")
+	}
+
+	// Get user by ID.
+	user, err := get(c.UserContext(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return user, nil
+}
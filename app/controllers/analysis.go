@@ -2,13 +2,18 @@ package controllers
 
 import "github.com/gofiber/fiber/v2"
 
+// Home method to greet clients on the root path.
+// @Description Greet clients, to check the API is reachable.
+// @Summary home
+// @Tags Home
+// @Produce json
+// @Success 200 {object} models.Response{path=string}
+// @Router /api/v1/ [get]
 func Home(c *fiber.Ctx) error {
+	// Return status 200 OK.
 	return c.JSON(fiber.Map{
-		"error": false,
-		"msg":   nil,
-		"data": fiber.Map{
-			"path": "/",
-			"msg":  "Welcome, you've found the home endpoint",
-		},
+		"status":  "success",
+		"message": "Welcome, you've found the home endpoint",
+		"path":    "/",
 	})
 }
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// auditUserAgentMaxLength limits the stored user agent, it is sent by the client.
+const auditUserAgentMaxLength = 512
+
+// authAttempt struct to describe an auth request, counted and audited once the handler returns.
+// Handlers fill in the user, once known.
+type authAttempt struct {
+	deps     *Dependencies
+	event    string
+	userID   uuid.UUID
+	metadata models.AuditMetadata
+}
+
+// newAuthAttempt method to start recording an auth request, like "signin".
+func (d *Dependencies) newAuthAttempt(event string) *authAttempt {
+	return &authAttempt{deps: d, event: event, metadata: models.AuditMetadata{}}
+}
+
+// record method to count the attempt and append it to the audit log, returned errors are failures.
+func (a *authAttempt) record(c *fiber.Ctx, err error) {
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = apperror.StatusOf(err)
+	}
+	ok := status < fiber.StatusBadRequest
+	metrics.AuthEvent(a.event, ok)
+
+	event := &models.AuditEvent{
+		Action:   "auth." + a.event,
+		Outcome:  models.AuditOutcomeSuccess,
+		Metadata: a.metadata,
+	}
+	if !ok {
+		event.Outcome = models.AuditOutcomeFailure
+	}
+	event.Metadata["status"] = strconv.Itoa(status)
+	if a.userID != uuid.Nil {
+		event.ActorID, event.TargetID = &a.userID, &a.userID
+	}
+	a.deps.audit(c, event)
+}
+
+// audit method to append an event of the current request to the audit log.
+// The request is not failed, if the log can't be written, the error is logged instead.
+func (d *Dependencies) audit(c *fiber.Ctx, event *models.AuditEvent) {
+	event.IP = c.IP()
+	event.UserAgent = c.Get(fiber.HeaderUserAgent)
+	if len(event.UserAgent) > auditUserAgentMaxLength {
+		event.UserAgent = event.UserAgent[:auditUserAgentMaxLength]
+	}
+	event.UserAgent = strings.ToValidUTF8(event.UserAgent, "")
+
+	if err := d.Audit.Append(c.UserContext(), event); err != nil {
+		slog.ErrorContext(c.UserContext(), "audit event could not be saved", "action", event.Action, "error", err)
+	}
+}
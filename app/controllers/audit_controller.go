@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// auditEventsMaxLimit is the maximum page size of the audit log.
+	auditEventsMaxLimit = 200
+
+	// auditExportBatchSize is the number of events read at once while exporting.
+	auditExportBatchSize = 500
+)
+
+// auditCSVHeader names the columns of the CSV export.
+var auditCSVHeader = []string{"id", "created_at", "actor_id", "target_id", "action", "outcome", "ip", "user_agent", "metadata", "prev_hash", "hash"}
+
+// GetAuditEvents method to list security audit events for admins.
+// @Description List audit events, newest first, with filters and cursor pagination.
+// @Summary list audit events
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param actor_id query string false "User ID who acted"
+// @Param target_id query string false "User ID acted on"
+// @Param action query string false "Action, like auth.signin"
+// @Param outcome query string false "Outcome (success or failure)"
+// @Param from query string false "Created at or after (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Param cursor query int false "Cursor from next_cursor of the previous page"
+// @Param limit query int false "Page size, max 200"
+// @Success 200 {object} models.Response{count=int,next_cursor=*int,events=[]models.AuditEvent}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/audit [get]
+func (a *AdminController) GetAuditEvents(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.AuditReadCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get filters from query.
+	filter, err := auditFilter(c)
+	if err != nil {
+		// Return status 400 and error message.
+		return err
+	}
+
+	// Get cursor pagination from query.
+	filter.Before = int64(c.QueryInt("cursor"))
+	filter.Limit = c.QueryInt("limit", 50)
+	if filter.Limit < 1 || filter.Limit > auditEventsMaxLimit {
+		filter.Limit = auditEventsMaxLimit
+	}
+
+	// Get one more event, to know if there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+	events, err := a.Audit.List(c.UserContext(), filter)
+	if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	var nextCursor interface{}
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = events[limit-1].ID
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":      "success",
+		"message":     nil,
+		"count":       len(events),
+		"next_cursor": nextCursor,
+		"events":      events,
+	})
+}
+
+// ExportAuditEvents method to download security audit events for admins.
+// @Description Download all audit events matching the filters as CSV or NDJSON, newest first.
+// @Summary export audit events
+// @Tags Admin
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "File format (csv or ndjson), csv by default"
+// @Param actor_id query string false "User ID who acted"
+// @Param target_id query string false "User ID acted on"
+// @Param action query string false "Action, like auth.signin"
+// @Param outcome query string false "Outcome (success or failure)"
+// @Param from query string false "Created at or after (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Success 200 {file} file "audit events"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/audit/export [get]
+func (a *AdminController) ExportAuditEvents(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.AuditReadCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get filters from query.
+	filter, err := auditFilter(c)
+	if err != nil {
+		// Return status 400 and error message.
+		return err
+	}
+
+	// Check the requested format.
+	format := c.Query("format", "csv")
+	switch format {
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	case "ndjson":
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	default:
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeBadRequest, "format must be csv or ndjson")
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="audit-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), format))
+
+	// Stream events page by page, the export may not fit in memory.
+	// The request context is gone once the handler returns.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+		cw := csv.NewWriter(w)
+		if format == "csv" {
+			cw.Write(auditCSVHeader)
+		}
+
+		filter.Limit = auditExportBatchSize
+		for {
+			events, err := a.Audit.List(ctx, filter)
+			if err == nil && format == "csv" {
+				err = writeAuditCSV(cw, events)
+			} else if err == nil {
+				err = writeAuditNDJSON(w, events)
+			}
+			if err != nil {
+				slog.Error("audit export failed", "error", err)
+				return
+			}
+			if len(events) < auditExportBatchSize {
+				break
+			}
+			filter.Before = events[len(events)-1].ID
+		}
+		cw.Flush()
+		w.Flush()
+	})
+
+	return nil
+}
+
+// VerifyAuditEvents method to check the hash chain of the audit log for admins.
+// @Description Recompute the hash chain of the audit log, to detect changed or removed events.
+// @Summary verify audit log
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} models.Response{valid=bool,checked=int,broken_at=int}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/audit/verify [get]
+func (a *AdminController) VerifyAuditEvents(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	if _, err := a.checkCredential(c, repository.AuditReadCredential); err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	checked, err := a.Audit.Verify(c.UserContext())
+	var chainErr *repository.ChainError
+	if errors.As(err, &chainErr) {
+		// Return status 200 OK with the first event breaking the chain.
+		return c.JSON(fiber.Map{
+			"status":    "success",
+			"message":   chainErr.Error(),
+			"valid":     false,
+			"checked":   checked,
+			"broken_at": chainErr.EventID,
+		})
+	} else if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"valid":   true,
+		"checked": checked,
+	})
+}
+
+// auditFilter func for getting audit log filters from query.
+func auditFilter(c *fiber.Ctx) (repository.AuditFilter, error) {
+	filter := repository.AuditFilter{
+		Action:  c.Query("action"),
+		Outcome: c.Query("outcome"),
+	}
+
+	for param, field := range map[string]**uuid.UUID{
+		"actor_id":  &filter.ActorID,
+		"target_id": &filter.TargetID,
+	} {
+		if value := c.Query(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return filter, apperror.BadRequest(apperror.CodeBadRequest, param+" must be a UUID")
+			}
+			*field = &id
+		}
+	}
+
+	for param, field := range map[string]*time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, apperror.BadRequest(apperror.CodeBadRequest, param+" must be a RFC 3339 time")
+			}
+			*field = t
+		}
+	}
+
+	return filter, nil
+}
+
+// writeAuditCSV func for writing events as CSV rows.
+func writeAuditCSV(cw *csv.Writer, events []models.AuditEvent) error {
+	for _, e := range events {
+		metadata, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return err
+		}
+		record := []string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			uuidString(e.ActorID),
+			uuidString(e.TargetID),
+			e.Action,
+			e.Outcome,
+			e.IP,
+			e.UserAgent,
+			string(metadata),
+			e.PrevHash,
+			e.Hash,
+		}
+
+		// Client values like the user agent must not run as spreadsheet formulas.
+		for i, field := range record {
+			if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+				record[i] = "'" + field
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return cw.Error()
+}
+
+// writeAuditNDJSON func for writing events as one JSON object per line.
+func writeAuditNDJSON(w *bufio.Writer, events []models.AuditEvent) error {
+	enc := json.NewEncoder(w)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uuidString func for formatting an optional ID, empty if not set.
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+
+	return id.String()
+}
@@ -2,51 +2,61 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"time"
 
 	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
 	"github.com/Figbase/api/pkg/utils"
 
-	"github.com/Figbase/api/platform/cache"
-	"github.com/Figbase/api/platform/database"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+// AuthController struct to describe sign up, sign in, sign out, token renewal and email confirmation handlers.
+type AuthController struct {
+	*Dependencies
+}
+
 // UserSignUp method to create a new user.
 // @Description Create a new user.
 // @Summary create a new user
 // @Tags User
 // @Accept json
 // @Produce json
+// @Param firstname body string true "First name"
+// @Param lastname body string true "Last name"
 // @Param email body string true "Email"
 // @Param password body string true "Password"
-// @Param user_role body string true "User role"
-// @Success 200 {object} models.User
-// @Router /v1/auth/signup [post]
-func UserSignUp(c *fiber.Ctx) error {
+// @Param invite_token body string false "Invite token"
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 200 {object} models.Response{user=models.User,tokens=object{access=string,refresh=string}}
+// @Failure default {object} apperror.Problem "problem details"
+// @Router /api/v1/auth/signup [post]
+func (a *AuthController) UserSignUp(c *fiber.Ctx) (err error) {
+	// Count and audit the attempt by response status.
+	attempt := a.newAuthAttempt("signup")
+	defer func() { attempt.record(c, err) }()
+
 	// Create a new user auth struct.
 	signUp := &models.SignUp{}
 
 	// Checking received data from JSON body.
 	if err := c.BodyParser(signUp); err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.InvalidBody(err)
 	}
 
+	// Emails are compared case-insensitively.
+	signUp.Email = strings.ToLower(signUp.Email)
+
 	// Check if the email has been used to signup before.
-	existingUser := &models.User{}
-	if err := database.DB.Db.Where("email = ?", signUp.Email).First(existingUser).Error; err == nil {
+	if _, err := a.Users.GetByEmail(c.UserContext(), signUp.Email); err == nil {
 		// Return status 400 and error message indicating that the email is already registered.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Email address is already registered",
-		})
+		return apperror.BadRequest(apperror.CodeEmailTaken, "Email address is already registered")
 	}
 
 	// Create a new validator for a User model.
@@ -55,20 +65,32 @@ func UserSignUp(c *fiber.Ctx) error {
 	// Validate sign up fields.
 	if err := validate.Struct(signUp); err != nil {
 		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": utils.ValidatorErrors(err),
-		})
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Get invitation, if the user signs up from an invite link.
+	var invitation *models.Invitation
+	if signUp.InviteToken != "" {
+		pending, err := a.pendingInvitation(c.UserContext(), signUp.InviteToken)
+		if err != nil {
+			// Return status 400 and error message.
+			return err
+		}
+
+		// Invitation can only be accepted by its recipient.
+		if !strings.EqualFold(signUp.Email, pending.Email) {
+			// Return status 403 and error message.
+			return apperror.Forbidden(apperror.CodeInvitationMismatch, "invitation was sent to a different email address")
+		}
+
+		invitation = pending
 	}
 
-	// Checking role from sign up data.
-	role, err := utils.VerifyRole("user")
+	// Every new user gets the default role, the invitation role applies to the organization only.
+	role, err := utils.VerifyRole(repository.UserRoleName)
 	if err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.BadRequest(apperror.CodeInvalidRole, err.Error())
 	}
 
 	// Create a new user struct.
@@ -80,69 +102,48 @@ func UserSignUp(c *fiber.Ctx) error {
 	user.Email = signUp.Email
 	user.FirstName = signUp.FirstName
 	user.LastName = signUp.LastName
-	user.PasswordHash = utils.GeneratePassword(signUp.Password)
+	user.PasswordHash = hashPassword(c.UserContext(), signUp.Password)
 	user.UserStatus = 1 // 0 == blocked, 1 == active
 	user.UserRole = role
 
 	// Validate user fields.
 	if err := validate.Struct(user); err != nil {
 		// Return, if some fields are not valid.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": utils.ValidatorErrors(err),
-		})
-	}
-
-	// Checking received data from JSON body.
-	if err := c.BodyParser(user); err != nil {
-		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.Validation(utils.ValidatorErrors(err))
 	}
 
-	// Create a new user with validated data.
-	database.DB.Db.Create(&user)
-
-	// Get role credentials from created user.
-	credentials, err := utils.GetCredentialsByRole(user.UserRole)
-	if err != nil {
-		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// Create a new user with validated data and join the organization, if the user was invited.
+	err = a.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		if err := a.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		if invitation == nil {
+			return nil
+		}
+		_, err := a.acceptInvitation(ctx, invitation, user.ID)
+		return err
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		// Return status 400, the email was registered meanwhile.
+		return apperror.BadRequest(apperror.CodeEmailTaken, "Email address is already registered")
+	} else if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
 	}
+	attempt.userID = user.ID
 
-	// Generate a new pair of access and refresh tokens.
-	tokens, err := utils.GenerateNewTokens(user.ID.String(), credentials)
+	// Generate a new pair of access and refresh tokens and save the session.
+	tokens, err := a.issueTokens(c.UserContext(), user)
 	if err != nil {
 		// Return status 500 and token generation error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.Internal(err)
 	}
 
-	// Create a new Redis connection.
-	connRedis, err := cache.RedisConnection()
+	// Send refresh token in the configured transport.
+	body, err := a.sendTokens(c, tokens)
 	if err != nil {
-		// Return status 500 and Redis connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
-	}
-
-	// Save refresh token to Redis.
-	errSaveToRedis := connRedis.Set(context.Background(), user.ID.String(), tokens.Refresh, 0).Err()
-	if errSaveToRedis != nil {
-		// Return status 500 and Redis connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": errSaveToRedis.Error(),
-		})
+		// Return status 500 and error message.
+		return apperror.Internal(err)
 	}
 
 	// Delete password hash field from JSON view.
@@ -153,10 +154,7 @@ func UserSignUp(c *fiber.Ctx) error {
 		"status":  "success",
 		"message": nil,
 		"user":    user,
-		"tokens": fiber.Map{
-			"access":  tokens.Access,
-			"refresh": tokens.Refresh,
-		},
+		"tokens":  body,
 	})
 }
 
@@ -168,87 +166,65 @@ func UserSignUp(c *fiber.Ctx) error {
 // @Produce json
 // @Param email body string true "User Email"
 // @Param password body string true "User Password"
-// @Success 200 {string} status "ok"
-// @Router /v1/auth/signin [post]
-func UserSignIn(c *fiber.Ctx) error {
+// @Success 200 {object} models.Response{user=models.User,tokens=object{access=string,refresh=string}}
+// @Failure default {object} apperror.Problem "problem details"
+// @Router /api/v1/auth/signin [post]
+func (a *AuthController) UserSignIn(c *fiber.Ctx) (err error) {
+	// Count and audit the attempt by response status.
+	attempt := a.newAuthAttempt("signin")
+	defer func() { attempt.record(c, err) }()
+
 	// Create a new user auth struct.
 	signIn := &models.SignIn{}
 
 	// Checking received data from JSON body.
 	if err := c.BodyParser(signIn); err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.InvalidBody(err)
 	}
 
-	// Create a new user struct.
-	user := &models.User{}
-
 	// Get user by email.
-	result := database.DB.Db.Where("email = ?", signIn.Email).First(&user)
+	user, err := a.Users.GetByEmail(c.UserContext(), signIn.Email)
 
 	// Check if the user was not found.
-	if result.Error != nil {
+	if err != nil {
+		// The append-only log can't be erased, so the unknown email is not kept in any form,
+		// credential stuffing shows by the IP address and reason of the attempts.
+		attempt.metadata["reason"] = "unknown_email"
+
 		// Return, if user not found.
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"status":  "error",
-			"message": "The email address does not exist",
-		})
+		return apperror.NotFound(apperror.CodeEmailNotFound, "The email address does not exist")
 	}
+	attempt.userID = user.ID
 
 	// Compare given user password with stored in found user.
-	compareUserPassword := utils.ComparePasswords(user.PasswordHash, signIn.Password)
+	compareUserPassword := comparePassword(c.UserContext(), user.PasswordHash, signIn.Password)
 	if !compareUserPassword {
+		attempt.metadata["reason"] = "wrong_password"
+
 		// Return, if password is not compare to stored in database.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "The email address or password is wrong",
-		})
+		return apperror.BadRequest(apperror.CodeInvalidCredentials, "The email address or password is wrong")
 	}
 
-	// Get role credentials from founded user.
-	credentials, err := utils.GetCredentialsByRole(user.UserRole)
-	if err != nil {
-		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// Check if the user account was deactivated.
+	if user.UserStatus != 1 {
+		attempt.metadata["reason"] = "blocked"
+		// Return status 403 and error message.
+		return apperror.Forbidden(apperror.CodeAccountBlocked, "The user account is blocked")
 	}
 
-	// Generate a new pair of access and refresh tokens.
-	tokens, err := utils.GenerateNewTokens(user.ID.String(), credentials)
+	// Generate a new pair of access and refresh tokens and save the session.
+	tokens, err := a.issueTokens(c.UserContext(), user)
 	if err != nil {
 		// Return status 500 and token generation error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.Internal(err)
 	}
 
-	// Define user ID.
-	userID := user.ID.String()
-
-	// Create a new Redis connection.
-	connRedis, err := cache.RedisConnection()
+	// Send refresh token in the configured transport.
+	body, err := a.sendTokens(c, tokens)
 	if err != nil {
-		// Return status 500 and Redis connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
-	}
-
-	// Save refresh token to Redis.
-	errSaveToRedis := connRedis.Set(context.Background(), userID, tokens.Refresh, 0).Err()
-	if errSaveToRedis != nil {
-		// Return status 500 and Redis connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": errSaveToRedis.Error(),
-		})
+		// Return status 500 and error message.
+		return apperror.Internal(err)
 	}
 
 	// Delete password hash field from JSON view.
@@ -259,10 +235,7 @@ func UserSignIn(c *fiber.Ctx) error {
 		"status":  "success",
 		"message": nil,
 		"user":    user,
-		"tokens": fiber.Map{
-			"access":  tokens.Access,
-			"refresh": tokens.Refresh,
-		},
+		"tokens":  body,
 	})
 }
 
@@ -273,42 +246,89 @@ func UserSignIn(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
 // @Security ApiKeyAuth
-// @Router /v1/auth/signout [post]
-func UserSignOut(c *fiber.Ctx) error {
+// @Router /api/v1/auth/signout [post]
+func (a *AuthController) UserSignOut(c *fiber.Ctx) (err error) {
+	// Count and audit the attempt by response status.
+	attempt := a.newAuthAttempt("signout")
+	defer func() { attempt.record(c, err) }()
+
 	// Get claims from JWT.
 	claims, err := utils.ExtractTokenMetadata(c)
 	if err != nil {
 		// Return status 500 and JWT parse error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.Internal(err)
 	}
+	attempt.userID = claims.UserID
 
-	// Define user ID.
-	userID := claims.UserID.String()
-
-	// Create a new Redis connection.
-	connRedis, err := cache.RedisConnection()
-	if err != nil {
-		// Return status 500 and Redis connection error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// Delete refresh token from session store.
+	if err := a.Sessions.Delete(c.UserContext(), claims.UserID); err != nil {
+		// Return status 500 and session store error.
+		return apperror.Internal(err)
 	}
 
-	// Save refresh token to Redis.
-	errDelFromRedis := connRedis.Del(context.Background(), userID).Err()
-	if errDelFromRedis != nil {
-		// Return status 500 and Redis deletion error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": errDelFromRedis.Error(),
-		})
+	// Clear refresh token cookie, the browser may still keep it.
+	if a.Session.Mode == utils.SessionModeCookie {
+		c.Cookie(utils.ClearRefreshTokenCookie(a.Session))
 	}
 
 	// Return status 204 no content.
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// revokeSessions method to delete refresh token of the user from the session store.
+func (d *Dependencies) revokeSessions(ctx context.Context, userID uuid.UUID) error {
+	return d.Sessions.Delete(ctx, userID)
+}
+
+// issueTokens method to generate a new pair of tokens for the user and save the refresh token hash to the session store.
+func (d *Dependencies) issueTokens(ctx context.Context, user *models.User) (*utils.Tokens, error) {
+	// Get role credentials from the user.
+	credentials, err := utils.GetCredentialsByRole(user.UserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate a new pair of access and refresh tokens.
+	tokens, err := generateTokens(ctx, user.ID.String(), credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save hash of the refresh token, replacing the previous session.
+	if err := d.Sessions.Save(ctx, user.ID, utils.HashToken(tokens.Refresh)); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// sendTokens method to get the tokens object of a response, in the configured session mode.
+// In the cookie mode the refresh token is set in an HttpOnly cookie instead of the JSON body,
+// along with the CSRF cookie if the client has none yet.
+func (d *Dependencies) sendTokens(c *fiber.Ctx, tokens *utils.Tokens) (fiber.Map, error) {
+	if d.Session.Mode != utils.SessionModeCookie {
+		return fiber.Map{
+			"access":  tokens.Access,
+			"refresh": tokens.Refresh,
+		}, nil
+	}
+
+	// Expire the cookie with the refresh token.
+	expires, err := utils.ParseRefreshToken(tokens.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	c.Cookie(utils.NewRefreshTokenCookie(d.Session, tokens.Refresh, time.Unix(expires, 0)))
+
+	if c.Cookies(utils.CSRFTokenCookie) == "" {
+		cookie, err := utils.NewCSRFCookie(d.Session)
+		if err != nil {
+			return nil, err
+		}
+		c.Cookie(cookie)
+	}
+
+	return fiber.Map{"access": tokens.Access}, nil
+}
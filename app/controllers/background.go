@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks jobs which outlive the request that started them.
+var background sync.WaitGroup
+
+// runInBackground func for starting a job which shutdown waits for.
+func runInBackground(job func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		job()
+	}()
+}
+
+// WaitBackground func for waiting until background jobs finish or the context ends.
+func WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/platform/config"
+)
+
+// Dependencies struct to describe settings and stores shared by all controllers, set up once on startup.
+type Dependencies struct {
+	App     *config.App
+	Session *config.Session // how browser clients keep refresh tokens
+
+	Tx            repository.Transactor // runs changes of several repositories as one unit
+	Users         repository.UserRepository
+	Organizations repository.OrganizationRepository
+	Invitations   repository.InvitationRepository
+	Privacy       repository.PrivacyRepository
+	Scim          repository.ScimRepository
+	Sessions      repository.SessionStore // refresh token hashes
+	Tokens        repository.TokenStore   // short-lived tokens, like pending email changes
+	Audit         repository.AuditStore   // security audit log
+}
+
+// Controllers struct to describe all handlers, wired with the same dependencies.
+type Controllers struct {
+	Auth          *AuthController
+	Organizations *OrganizationController
+	Profile       *ProfileController
+	Admin         *AdminController
+	Scim          *ScimController
+}
+
+// New func for creating all handlers on the given dependencies.
+func New(deps *Dependencies) *Controllers {
+	return &Controllers{
+		Auth:          &AuthController{deps},
+		Organizations: &OrganizationController{deps},
+		Profile:       &ProfileController{deps},
+		Admin:         &AdminController{deps},
+		Scim:          &ScimController{deps},
+	}
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/Figbase/api/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDocs method to open Swagger UI, its assets are loaded relative to the trailing slash.
+func GetDocs(c *fiber.Ctx) error {
+	// Serve Swagger UI from its assets.
+	if strings.HasSuffix(c.Path(), "/") {
+		return c.Next()
+	}
+
+	// Return status 301 to Swagger UI.
+	return c.Redirect("/api/docs/", fiber.StatusMovedPermanently)
+}
+
+// GetOpenAPI method to get the OpenAPI 3 document of the API.
+func GetOpenAPI(c *fiber.Ctx) error {
+	// Return status 200 OK.
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(docs.OpenAPI)
+}
+
+// GetSwaggerInitializer method to get the script starting Swagger UI with the OpenAPI document.
+func GetSwaggerInitializer(c *fiber.Ctx) error {
+	// Return status 200 OK.
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJavaScriptCharsetUTF8)
+	return c.Send(docs.SwaggerInitializer)
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ChangeEmail method to request a new email for the signed in user.
+// @Description Send a confirmation link to the new email and a notice to the current one. The email is changed only after confirmation.
+// @Summary request email change
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param email body string true "New email"
+// @Param password body string true "Password"
+// @Success 202 {object} models.Response
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/email [post]
+func (p *ProfileController) ChangeEmail(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new change email struct.
+	change := &models.ChangeEmail{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(change); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate change email fields.
+	if err := utils.NewValidator().Struct(change); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Changing the login identifier needs the password again.
+	if !comparePassword(c.UserContext(), user.PasswordHash, change.Password) {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeWrongPassword, "The password is wrong")
+	}
+
+	// Check if the email has been used by another account.
+	email := strings.ToLower(change.Email)
+	if p.emailTaken(c.UserContext(), email, user.ID) {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeEmailTaken, "Email address is already registered")
+	}
+
+	// Generate a new confirmation token, bound to the user.
+	secret, err := utils.GenerateRandomToken()
+	if err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+	token := user.ID.String() + "." + secret
+
+	// Save pending change, it replaces an earlier request.
+	pending, _ := json.Marshal(&models.EmailChange{
+		TokenHash: utils.HashToken(token),
+		Email:     email,
+	})
+
+	expires := p.emailChangeExpiration()
+	if err := p.Tokens.Save(c.UserContext(), emailChangeKey(user.ID), pending, expires); err != nil {
+		// Return status 500 and token store error.
+		return apperror.Internal(err)
+	}
+
+	// Send confirmation link to the new email.
+	if err := mailer.Send(&mailer.Message{
+		To:      email,
+		Subject: "Confirm your new Figbase email address",
+		Body: fmt.Sprintf(
+			"Confirm this address for your Figbase account: %s/email/confirm?token=%s\n\nThis link expires in %s.",
+			p.App.BaseURL,
+			token,
+			expires,
+		),
+	}); err != nil {
+		// Return status 500 and mailer error.
+		return apperror.Internal(err)
+	}
+
+	// Send notice to the current email.
+	if err := mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Your Figbase email address is being changed",
+		Body: fmt.Sprintf(
+			"A change of your Figbase account email to %s was requested. If this wasn't you, change your password now.",
+			email,
+		),
+	}); err != nil {
+		// Return status 500 and mailer error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 202 accepted.
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Confirmation link was sent to the new email address",
+	})
+}
+
+// ConfirmEmail method to swap the email after the confirmation token is consumed.
+// @Description Consume the confirmation token, change the email and re-issue tokens, ending all other sessions.
+// @Summary confirm email change
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param token body string true "Confirmation token"
+// @Success 200 {object} models.Response{user=models.User,tokens=object{access=string,refresh=string}}
+// @Failure default {object} apperror.Problem "problem details"
+// @Router /api/v1/auth/email/confirm [post]
+func (a *AuthController) ConfirmEmail(c *fiber.Ctx) error {
+	// Create a new confirm email struct.
+	confirm := &models.ConfirmEmail{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(confirm); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate confirm email fields.
+	if err := utils.NewValidator().Struct(confirm); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Get user ID from token.
+	id, _, _ := strings.Cut(confirm.Token, ".")
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeConfirmationInvalid, "confirmation token is not valid or has expired")
+	}
+
+	// Get pending change and check it belongs to this token.
+	pending := &models.EmailChange{}
+	raw, err := a.Tokens.Get(c.UserContext(), emailChangeKey(userID))
+	if err != nil || json.Unmarshal(raw, pending) != nil || pending.TokenHash != utils.HashToken(confirm.Token) {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeConfirmationInvalid, "confirmation token is not valid or has expired")
+	}
+
+	// Consume the token, only one request can win.
+	if deleted, err := a.Tokens.Delete(c.UserContext(), emailChangeKey(userID)); err != nil || !deleted {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeConfirmationInvalid, "confirmation token is not valid or has expired")
+	}
+
+	// Get user by ID.
+	user, err := a.Users.GetByID(c.UserContext(), userID)
+	if err != nil {
+		// Return, if user not found.
+		return apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	}
+
+	// Check if the user account was deactivated after the change was requested.
+	if user.UserStatus != 1 {
+		// Return status 403 and error message.
+		return apperror.Forbidden(apperror.CodeAccountBlocked, "The user account is blocked")
+	}
+
+	// Check again, the email could have been registered meanwhile.
+	if a.emailTaken(c.UserContext(), pending.Email, user.ID) {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeEmailTaken, "Email address is already registered")
+	}
+
+	// Swap the email.
+	user.Email = pending.Email
+	user.UpdatedAt = time.Now()
+	if err := a.Users.Update(c.UserContext(), user); errors.Is(err, repository.ErrDuplicate) {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeEmailTaken, "Email address is already registered")
+	} else if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Re-issue tokens, the new refresh token replaces all earlier sessions.
+	tokens, err := a.issueTokens(c.UserContext(), user)
+	if err != nil {
+		// Return status 500 and token generation error.
+		return apperror.Internal(err)
+	}
+
+	// Send refresh token in the configured transport.
+	body, err := a.sendTokens(c, tokens)
+	if err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+		"tokens":  body,
+	})
+}
+
+// emailTaken method to check, if the email is used by another account.
+func (d *Dependencies) emailTaken(ctx context.Context, email string, userID uuid.UUID) bool {
+	existing, err := d.Users.GetByEmail(ctx, email)
+
+	return err == nil && existing.ID != userID
+}
+
+// emailChangeKey func for getting Redis key of the user's pending email change.
+func emailChangeKey(userID uuid.UUID) string {
+	return "email_change:" + userID.String()
+}
+
+// emailChangeExpiration method to get lifetime of an email confirmation token.
+func (d *Dependencies) emailChangeExpiration() time.Duration {
+	return time.Hour * time.Duration(d.App.EmailChangeExpireHours)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// healthCheckTimeout limits how long a single dependency check may take.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck func to describe a dependency check, nil error means healthy.
+type HealthCheck func(ctx context.Context) error
+
+// HealthController struct to describe liveness and readiness handlers.
+type HealthController struct {
+	checks map[string]HealthCheck
+	ready  atomic.Bool
+}
+
+// NewHealthController func for creating health handlers with dependency checks by name.
+// The app reports ready until SetReady(false) is called on shutdown.
+func NewHealthController(checks map[string]HealthCheck) *HealthController {
+	h := &HealthController{checks: checks}
+	h.ready.Store(true)
+
+	return h
+}
+
+// SetReady method to flip readiness, so the orchestrator stops sending traffic before shutdown.
+func (h *HealthController) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Liveness method to report the process is alive.
+// @Description Report the process is alive, without checking dependencies.
+// @Summary liveness probe
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object{status=string}
+// @Router /healthz [get]
+func (h *HealthController) Liveness(c *fiber.Ctx) error {
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// Readiness method to report the app can serve traffic, with status and latency of each dependency.
+// @Description Check Postgres, Redis and migrations. Not ready while shutting down.
+// @Summary readiness probe
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object{status=string,shutting_down=bool,checks=object}
+// @Failure 503 {object} object{status=string,shutting_down=bool,checks=object} "not ready"
+// @Router /readyz [get]
+func (h *HealthController) Readiness(c *fiber.Ctx) error {
+	// Run all checks at once, each with its own timeout.
+	results := make(map[string]fiber.Map, len(h.checks))
+	healthy := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(c.UserContext(), healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := fiber.Map{
+				"status":     "ok",
+				"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result["status"] = "error"
+				result["error"] = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if err != nil {
+				healthy = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	// Not ready while shutting down, even if dependencies are fine.
+	shuttingDown := !h.ready.Load()
+
+	status, code := "ok", fiber.StatusOK
+	if !healthy || shuttingDown {
+		status, code = "error", fiber.StatusServiceUnavailable
+	}
+
+	// Return status 200 OK or 503 service unavailable.
+	return c.Status(code).JSON(fiber.Map{
+		"status":        status,
+		"shutting_down": shuttingDown,
+		"checks":        results,
+	})
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CreateInvitation method to invite a new member to an organization.
+// @Description Invite a new member to an organization by email.
+// @Summary invite a new member to an organization
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param email body string true "Email"
+// @Param role body string true "Role"
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 200 {object} models.Response{invitation=models.Invitation}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs/{id}/invitations [post]
+func (o *OrganizationController) CreateInvitation(c *fiber.Ctx) error {
+	// Get organization administered by the current user.
+	org, claims, err := o.adminOrganization(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new invitation struct.
+	create := &models.CreateInvitation{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(create); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate invitation fields.
+	if err := utils.NewValidator().Struct(create); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Checking role from invitation data.
+	role, err := utils.VerifyRole(create.Role)
+	if err != nil {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeInvalidRole, err.Error())
+	}
+
+	// Check if there is already a pending invitation for this email.
+	email := strings.ToLower(create.Email)
+	if _, err := o.Invitations.FindPending(c.UserContext(), org.ID, email); err == nil {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeInvitationExists, "a pending invitation already exists for this email")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Set initialized default data for invitation.
+	invitation := &models.Invitation{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		OrganizationID: org.ID,
+		InvitedBy:      claims.UserID,
+		Email:          email,
+		Role:           role,
+		Status:         models.InvitationStatusPending,
+	}
+
+	// Sign, save and email the invitation.
+	if err := o.sendInvitation(c.UserContext(), invitation, org); errors.Is(err, repository.ErrDuplicate) {
+		// Return status 409, if a concurrent request invited the email first.
+		return apperror.Conflict(apperror.CodeInvitationExists, "a pending invitation already exists for this email")
+	} else if err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    nil,
+		"invitation": invitation,
+	})
+}
+
+// GetInvitations method to list pending invitations of an organization.
+// @Description List pending invitations of an organization.
+// @Summary list pending invitations of an organization
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} models.Response{count=int,invitations=[]models.Invitation}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs/{id}/invitations [get]
+func (o *OrganizationController) GetInvitations(c *fiber.Ctx) error {
+	// Get organization administered by the current user.
+	org, _, err := o.adminOrganization(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get all pending invitations.
+	invitations, err := o.Invitations.ListPending(c.UserContext(), org.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":      "success",
+		"message":     nil,
+		"count":       len(invitations),
+		"invitations": invitations,
+	})
+}
+
+// ResendInvitation method to re-issue and re-send a pending invitation.
+// @Description Re-issue the invite token with a new expiration time and email it again.
+// @Summary re-send a pending invitation
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param invitationID path string true "Invitation ID"
+// @Success 200 {object} models.Response{invitation=models.Invitation}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs/{id}/invitations/{invitationID}/resend [post]
+func (o *OrganizationController) ResendInvitation(c *fiber.Ctx) error {
+	// Get organization administered by the current user.
+	org, _, err := o.adminOrganization(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get pending invitation by ID.
+	invitation, err := o.organizationInvitation(c, org)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Sign, save and email the invitation again, previous token stops working.
+	if err := o.sendInvitation(c.UserContext(), invitation, org); err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    nil,
+		"invitation": invitation,
+	})
+}
+
+// RevokeInvitation method to revoke a pending invitation.
+// @Description Revoke a pending invitation, its token can't be accepted anymore.
+// @Summary revoke a pending invitation
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param invitationID path string true "Invitation ID"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs/{id}/invitations/{invitationID} [delete]
+func (o *OrganizationController) RevokeInvitation(c *fiber.Ctx) error {
+	// Get organization administered by the current user.
+	org, _, err := o.adminOrganization(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get pending invitation by ID.
+	invitation, err := o.organizationInvitation(c, org)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Mark invitation as revoked.
+	invitation.Status = models.InvitationStatusRevoked
+	invitation.UpdatedAt = time.Now()
+	if err := o.Invitations.Save(c.UserContext(), invitation); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AcceptInvitation method to join an organization as an existing user.
+// @Description Accept an invitation as the signed in user.
+// @Summary accept an invitation
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param token body string true "Invite token"
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 200 {object} models.Response{membership=models.Membership}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/invitations/accept [post]
+func (o *OrganizationController) AcceptInvitation(c *fiber.Ctx) error {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return apperror.Internal(err)
+	}
+
+	// Create a new accept invitation struct.
+	accept := &models.AcceptInvitation{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(accept); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate accept invitation fields.
+	if err := utils.NewValidator().Struct(accept); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Get invitation by the given token.
+	invitation, err := o.pendingInvitation(c.UserContext(), accept.Token)
+	if err != nil {
+		// Return status 400 and error message.
+		return err
+	}
+
+	// Get current user by ID.
+	user, err := o.Users.GetByID(c.UserContext(), claims.UserID)
+	if err != nil {
+		// Return, if user not found.
+		return apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	}
+
+	// Invitation can only be accepted by its recipient.
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		// Return status 403 and error message.
+		return apperror.Forbidden(apperror.CodeInvitationMismatch, "invitation was sent to a different email address")
+	}
+
+	// Create membership and close the invitation.
+	membership, err := o.acceptInvitation(c.UserContext(), invitation, user.ID)
+	if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    nil,
+		"membership": membership,
+	})
+}
+
+// adminOrganization method to get organization from path, if the current user administers it.
+func (d *Dependencies) adminOrganization(c *fiber.Ctx) (*models.Organization, *utils.TokenMetadata, error) {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+
+	// Parse organization ID from path.
+	orgID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, nil, apperror.BadRequest(apperror.CodeInvalidID, "organization ID is not valid")
+	}
+
+	// Get organization by ID.
+	org, err := d.Organizations.GetByID(c.UserContext(), orgID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, apperror.NotFound(apperror.CodeOrganizationNotFound, "organization with the given ID is not found")
+	} else if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+
+	// Only organization admins can manage invitations.
+	admin, err := d.isOrganizationAdmin(c.UserContext(), org.ID, claims.UserID)
+	if err != nil {
+		return nil, nil, apperror.Internal(err)
+	}
+	if !admin {
+		return nil, nil, apperror.Forbidden(apperror.CodePermissionDenied, "permission denied, check credentials of your token")
+	}
+
+	return org, claims, nil
+}
+
+// organizationInvitation method to get pending invitation of the organization from path.
+func (d *Dependencies) organizationInvitation(c *fiber.Ctx, org *models.Organization) (*models.Invitation, error) {
+	// Parse invitation ID from path.
+	invitationID, err := uuid.Parse(c.Params("invitationID"))
+	if err != nil {
+		return nil, apperror.BadRequest(apperror.CodeInvalidID, "invitation ID is not valid")
+	}
+
+	// Get pending invitation by ID.
+	invitation, err := d.Invitations.GetByID(c.UserContext(), invitationID)
+	if errors.Is(err, repository.ErrNotFound) ||
+		err == nil && (invitation.OrganizationID != org.ID || invitation.Status != models.InvitationStatusPending) {
+		return nil, apperror.NotFound(apperror.CodeInvitationNotFound, "pending invitation with the given ID is not found")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return invitation, nil
+}
+
+// sendInvitation method to sign a new invite token, save the invitation and email it.
+func (d *Dependencies) sendInvitation(ctx context.Context, invitation *models.Invitation, org *models.Organization) error {
+	// Set a new expiration time.
+	invitation.ExpiresAt = utils.InviteExpiresAt()
+
+	// Generate a new signed invite token.
+	token, err := utils.GenerateInviteToken(invitation.ID.String(), invitation.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Only the latest issued token is accepted.
+	invitation.TokenHash = utils.HashToken(token)
+
+	// Save invitation.
+	invitation.UpdatedAt = time.Now()
+	if err := d.Invitations.Save(ctx, invitation); err != nil {
+		return err
+	}
+
+	// Send invite token to the recipient.
+	return mailer.Send(&mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s on Figbase", org.Name),
+		Body: fmt.Sprintf(
+			"You have been invited to join %s on Figbase.\n\nAccept the invitation: %s/invitations/accept?token=%s\n\nThis link expires at %s.",
+			org.Name,
+			d.App.BaseURL,
+			token,
+			invitation.ExpiresAt.UTC().Format(time.RFC1123),
+		),
+	})
+}
+
+// pendingInvitation method to get a pending invitation by the given invite token.
+func (d *Dependencies) pendingInvitation(ctx context.Context, token string) (*models.Invitation, error) {
+	// Verify invite token.
+	metadata, err := utils.ParseInviteToken(token)
+	if err != nil {
+		return nil, apperror.BadRequest(apperror.CodeInvitationInvalid, err.Error())
+	}
+
+	// Get invitation by ID.
+	invitation, err := d.Invitations.GetByID(ctx, metadata.InvitationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperror.BadRequest(apperror.CodeInvitationInvalid, "invitation is not found")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	// Check invitation is still valid for this token.
+	switch {
+	case invitation.Status != models.InvitationStatusPending:
+		return nil, apperror.BadRequest(apperror.CodeInvitationInvalid, fmt.Sprintf("invitation is %s", invitation.Status))
+	case time.Now().After(invitation.ExpiresAt):
+		return nil, apperror.BadRequest(apperror.CodeInvitationInvalid, "invite token has expired")
+	case subtle.ConstantTimeCompare([]byte(invitation.TokenHash), []byte(utils.HashToken(token))) != 1:
+		return nil, apperror.BadRequest(apperror.CodeInvitationInvalid, "invite token has been replaced by a newer one")
+	}
+
+	return invitation, nil
+}
+
+// acceptInvitation method to create a membership for the user and mark the invitation accepted, in one transaction.
+func (d *Dependencies) acceptInvitation(ctx context.Context, invitation *models.Invitation, userID uuid.UUID) (*models.Membership, error) {
+	now := time.Now()
+
+	membership := &models.Membership{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+	}
+
+	err := d.Tx.Transaction(ctx, func(ctx context.Context) error {
+		// Add user to the organization, unless already a member.
+		if existing, err := d.Organizations.GetMembership(ctx, invitation.OrganizationID, userID); err == nil {
+			membership = existing
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		} else if err := d.Organizations.CreateMembership(ctx, membership); err != nil {
+			return err
+		}
+
+		// Close the invitation.
+		invitation.Status = models.InvitationStatusAccepted
+		invitation.AcceptedAt = &now
+		invitation.UpdatedAt = now
+		return d.Invitations.Save(ctx, invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
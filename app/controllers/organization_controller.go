@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OrganizationController struct to describe organization, invitation and SCIM token handlers.
+type OrganizationController struct {
+	*Dependencies
+}
+
+// CreateOrganization method to create a new organization.
+// @Description Create a new organization, the current user becomes its admin.
+// @Summary create a new organization
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param name body string true "Name"
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 200 {object} models.Response{organization=models.Organization}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs [post]
+func (o *OrganizationController) CreateOrganization(c *fiber.Ctx) error {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		// Return status 500 and JWT parse error.
+		return apperror.Internal(err)
+	}
+
+	// Create a new organization struct.
+	create := &models.CreateOrganization{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(create); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate organization fields.
+	if err := utils.NewValidator().Struct(create); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Set initialized default data for organization.
+	org := &models.Organization{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		Name:      create.Name,
+		OwnerID:   claims.UserID,
+	}
+
+	// Create organization and admin membership for the owner.
+	err = o.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		if err := o.Organizations.Create(ctx, org); err != nil {
+			return err
+		}
+
+		return o.Organizations.CreateMembership(ctx, &models.Membership{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now(),
+			OrganizationID: org.ID,
+			UserID:         claims.UserID,
+			Role:           repository.AdminRoleName,
+		})
+	})
+	if err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":       "success",
+		"message":      nil,
+		"organization": org,
+	})
+}
+
+// isOrganizationAdmin method to check, if the given user administers the organization.
+func (d *Dependencies) isOrganizationAdmin(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	membership, err := d.Organizations.GetMembership(ctx, orgID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return membership.Role == repository.AdminRoleName, nil
+}
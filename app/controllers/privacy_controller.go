@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// dataExportBuildTimeout is how long an export may stay pending, older ones were lost and are failed.
+const dataExportBuildTimeout = 30 * time.Minute
+
+// dataExportFailure is the error shown to the user for an export which could not be prepared.
+const dataExportFailure = "export could not be prepared, please try again"
+
+// RequestDataExport method to start preparing a personal data export of the signed in user.
+// @Description Start preparing an archive with all personal data of the signed in user. The user is emailed when it's ready.
+// @Summary request personal data export
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 202 {object} models.Response{export=models.DataExport}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/export [post]
+func (p *ProfileController) RequestDataExport(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Return the export being prepared, instead of starting another one.
+	if export, err := p.Privacy.FindPendingExport(c.UserContext(), user.ID); err == nil {
+		if time.Since(export.CreatedAt) < dataExportBuildTimeout {
+			// Return status 202 accepted.
+			return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+				"status":  "success",
+				"message": nil,
+				"export":  export,
+			})
+		}
+
+		// An export pending for so long was lost, like by a restart, so fail it and start over.
+		p.failDataExport(c.UserContext(), export.ID)
+	}
+
+	// Create a new export.
+	export := &models.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    user.ID,
+		Status:    models.DataExportStatusPending,
+	}
+	if err := p.Privacy.CreateExport(c.UserContext(), export); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Prepare the archive in background.
+	runInBackground(func() { p.buildDataExport(export.ID, user.ID) })
+
+	// Return status 202 accepted.
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"export":  export,
+	})
+}
+
+// GetDataExport method to get the status of a personal data export.
+// @Description Get the status of a personal data export of the signed in user.
+// @Summary get personal data export
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} models.Response{export=models.DataExport}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/exports/{id} [get]
+func (p *ProfileController) GetDataExport(c *fiber.Ctx) error {
+	// Get export of the current user by ID from path.
+	export, err := p.userDataExport(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"export":  export,
+	})
+}
+
+// DownloadDataExport method to download a ready personal data export.
+// @Description Download the archive of a ready personal data export of the signed in user.
+// @Summary download personal data export
+// @Tags Privacy
+// @Produce application/zip
+// @Param id path string true "Export ID"
+// @Success 200 {file} file "ZIP archive"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/exports/{id}/download [get]
+func (p *ProfileController) DownloadDataExport(c *fiber.Ctx) error {
+	// Get export of the current user by ID from path.
+	export, err := p.userDataExport(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Only ready and not expired exports can be downloaded.
+	if export.Status != models.DataExportStatusReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		// Return status 409 and error message.
+		return apperror.Conflict(apperror.CodeExportNotReady, "export is not ready or has expired")
+	}
+
+	// Return status 200 OK with archive.
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="figbase-export-%s.zip"`, export.ID))
+	return c.Send(export.Archive)
+}
+
+// EraseProfile method to erase the signed in user.
+// @Description Anonymize personal data of the signed in user in place, end the user's sessions and record a tombstone. Security audit events are kept as a retention exception.
+// @Summary erase own account
+// @Tags Privacy
+// @Accept json
+// @Produce json
+// @Param password body string true "Password"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/erase [post]
+func (p *ProfileController) EraseProfile(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new close account struct.
+	closeAccount := &models.CloseAccount{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(closeAccount); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate close account fields.
+	if err := utils.NewValidator().Struct(closeAccount); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Erasing the account needs the password again.
+	if !comparePassword(c.UserContext(), user.PasswordHash, closeAccount.Password) {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeWrongPassword, "The password is wrong")
+	}
+
+	// Erase personal data.
+	if err := p.eraseUser(c.UserContext(), user, user.ID); err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// EraseUser method to erase a user for admins.
+// @Description Anonymize personal data of a user in place, end the user's sessions and record a tombstone. Security audit events are kept as a retention exception. Erasing an erased user does nothing.
+// @Summary erase user by ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/users/{id}/erase [post]
+func (a *AdminController) EraseUser(c *fiber.Ctx) error {
+	// Check credentials of the current user.
+	claims, err := a.checkCredential(c, repository.UserManageCredential)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Get user by ID from path, deleted users can be erased too.
+	user, err := a.pathUser(c, a.Users.GetByIDWithDeleted)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Check if the user was erased before.
+	if _, err := a.Privacy.GetTombstone(c.UserContext(), user.ID); err == nil {
+		// Return status 204 no content, there is nothing left to erase.
+		return c.SendStatus(fiber.StatusNoContent)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Erase personal data, a concurrent erasure may have won meanwhile.
+	if err := a.eraseUser(c.UserContext(), user, claims.UserID); err != nil && !errors.Is(err, repository.ErrDuplicate) {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// userDataExport method to get export of the current user by ID from path.
+func (d *Dependencies) userDataExport(c *fiber.Ctx) (*models.DataExport, error) {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	// Parse export ID from path.
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest(apperror.CodeInvalidID, "export ID is not valid")
+	}
+
+	// Get export by ID, only its owner can see it.
+	export, err := d.Privacy.GetExport(c.UserContext(), exportID)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && export.UserID != claims.UserID {
+		return nil, apperror.NotFound(apperror.CodeExportNotFound, "export with the given ID is not found")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return export, nil
+}
+
+// buildDataExport method to collect personal data of the user into a ZIP archive.
+// It runs in background, after the request has ended.
+func (d *Dependencies) buildDataExport(exportID, userID uuid.UUID) {
+	ctx := context.Background()
+
+	export, err := d.Privacy.GetExport(ctx, exportID)
+	if err != nil {
+		slog.Error("data export could not be loaded", "export_id", exportID, "error", err)
+		d.failDataExport(ctx, exportID)
+		return
+	}
+
+	archive, buildErr := d.collectPersonalData(ctx, userID)
+
+	now := time.Now()
+	export.CompletedAt = &now
+	if buildErr != nil {
+		slog.Error("data export failed", "export_id", exportID, "error", buildErr)
+		export.Status = models.DataExportStatusFailed
+		export.Error = dataExportFailure
+	} else {
+		expires := now.Add(d.dataExportExpiration())
+		export.Status = models.DataExportStatusReady
+		export.Archive = archive
+		export.ExpiresAt = &expires
+	}
+
+	if err := d.Privacy.UpdateExport(ctx, export); err != nil {
+		slog.Error("data export could not be saved", "export_id", exportID, "error", err)
+		d.failDataExport(ctx, exportID)
+		return
+	}
+
+	// Let the user know the export is ready.
+	if buildErr != nil {
+		return
+	}
+	user, err := d.Users.GetByID(ctx, userID)
+	if err != nil {
+		return
+	}
+	if err := mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Your Figbase data export is ready",
+		Body: fmt.Sprintf(
+			"Your personal data export is ready to download: %s/api/v1/me/exports/%s/download\n\nThe link expires in %s.",
+			d.App.BaseURL,
+			exportID,
+			d.dataExportExpiration(),
+		),
+	}); err != nil {
+		slog.Error("data export notification failed", "export_id", exportID, "error", err)
+	}
+}
+
+// collectPersonalData method to build a ZIP archive with all personal data of the user.
+func (d *Dependencies) collectPersonalData(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	// Get user with password hash removed.
+	user, err := d.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+
+	// Get organizations the user belongs to.
+	memberships, err := d.Organizations.ListMemberships(ctx, repository.MembershipFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	organizations, err := d.Organizations.ListOwned(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get invitations sent by or to the user.
+	invitations, err := d.Invitations.ListForUser(ctx, userID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get session, only its hash is stored and it is never exported.
+	session := fiber.Map{"active": false}
+	if _, err := d.Sessions.Get(ctx, userID); err == nil {
+		session["active"] = true
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// Get security audit events the user did or was the target of.
+	auditEvents, err := d.Audit.List(ctx, repository.AuditFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Write every part as a JSON file into the archive.
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range map[string]interface{}{
+		"user.json":          user,
+		"memberships.json":   memberships,
+		"organizations.json": organizations,
+		"invitations.json":   invitations,
+		"sessions.json":      []fiber.Map{session},
+		"audit_events.json":  auditEvents,
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// eraseUser method to anonymize personal data of the user in place and record a tombstone.
+// Security audit events of the user are kept, see "Personal data" in README for the retention exception.
+func (d *Dependencies) eraseUser(ctx context.Context, user *models.User, requestedBy uuid.UUID) error {
+	now := time.Now()
+	email := user.Email
+	anonymized := fmt.Sprintf("erased+%s@erased.invalid", user.ID)
+
+	err := d.Tx.Transaction(ctx, func(ctx context.Context) error {
+		// Replace personal data, the row stays for referential integrity.
+		user.Email = anonymized
+		user.FirstName = ""
+		user.LastName = ""
+		user.PasswordHash = ""
+		user.Timezone = ""
+		user.Locale = ""
+		user.AvatarURL = ""
+		user.UserStatus = 0 // 0 == blocked, 1 == active
+		user.UpdatedAt = now
+		user.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		if err := d.Users.Update(ctx, user); err != nil {
+			return err
+		}
+
+		// Remove memberships and invitations addressed to the user.
+		if err := d.Organizations.DeleteUserMemberships(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := d.Invitations.RevokeForEmail(ctx, email, anonymized); err != nil {
+			return err
+		}
+
+		// Remove prepared exports.
+		if err := d.Privacy.DeleteUserExports(ctx, user.ID); err != nil {
+			return err
+		}
+
+		// Record the tombstone, so the erasure is provable.
+		return d.Privacy.CreateTombstone(ctx, &models.ErasureTombstone{
+			ID:          uuid.New(),
+			UserID:      user.ID,
+			RequestedBy: requestedBy,
+			ErasedAt:    now,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// End all sessions and pending email changes of the user.
+	if err := d.Sessions.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	_, err = d.Tokens.Delete(ctx, emailChangeKey(user.ID))
+
+	return err
+}
+
+// failDataExport method to mark a pending export failed on a best-effort basis, so the user can request another one.
+func (d *Dependencies) failDataExport(ctx context.Context, exportID uuid.UUID) {
+	if err := d.Privacy.FailExport(ctx, exportID, dataExportFailure, time.Now()); err != nil {
+		slog.Error("data export could not be failed", "export_id", exportID, "error", err)
+	}
+}
+
+// dataExportExpiration method to get how long a ready export can be downloaded.
+func (d *Dependencies) dataExportExpiration() time.Duration {
+	return time.Hour * time.Duration(d.App.DataExportExpireHours)
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProfileController struct to describe handlers of the signed in user's own account.
+type ProfileController struct {
+	*Dependencies
+}
+
+// GetProfile method to get the signed in user.
+// @Description Get the signed in user.
+// @Summary get own profile
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Response{user=models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me [get]
+func (p *ProfileController) GetProfile(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+	})
+}
+
+// UpdateProfile method to update the signed in user.
+// @Description Update names, timezone, locale or avatar of the signed in user.
+// @Summary update own profile
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param firstname body string false "First name"
+// @Param lastname body string false "Last name"
+// @Param timezone body string false "IANA timezone, e.g. Europe/Berlin"
+// @Param locale body string false "BCP 47 language tag, e.g. en-US"
+// @Param avatar_url body string false "Avatar URL"
+// @Success 200 {object} models.Response{user=models.User}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me [patch]
+func (p *ProfileController) UpdateProfile(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new update profile struct.
+	update := &models.UpdateProfile{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(update); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate profile fields.
+	if err := utils.NewValidator().Struct(update); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Set changed fields.
+	if update.FirstName != nil {
+		user.FirstName = *update.FirstName
+	}
+	if update.LastName != nil {
+		user.LastName = *update.LastName
+	}
+	if update.Timezone != nil {
+		user.Timezone = *update.Timezone
+	}
+	if update.Locale != nil {
+		user.Locale = *update.Locale
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = *update.AvatarURL
+	}
+
+	// Save changed fields.
+	user.UpdatedAt = time.Now()
+	if err := p.Users.Update(c.UserContext(), user); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Delete password hash field from JSON view.
+	user.PasswordHash = ""
+
+	// Return status 200 OK.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"user":    user,
+	})
+}
+
+// DeleteProfile method to close the account of the signed in user.
+// @Description Close the account of the signed in user and end the user's sessions.
+// @Summary close own account
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param password body string true "Password"
+// @Success 204 {string} status "ok"
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/me [delete]
+func (p *ProfileController) DeleteProfile(c *fiber.Ctx) error {
+	// Get current user from JWT.
+	user, err := p.currentUser(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Create a new close account struct.
+	closeAccount := &models.CloseAccount{}
+
+	// Checking received data from JSON body.
+	if err := c.BodyParser(closeAccount); err != nil {
+		// Return status 400 and error message.
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate close account fields.
+	if err := utils.NewValidator().Struct(closeAccount); err != nil {
+		// Return, if some fields are not valid.
+		return apperror.Validation(utils.ValidatorErrors(err))
+	}
+
+	// Closing the account needs the password again.
+	if !comparePassword(c.UserContext(), user.PasswordHash, closeAccount.Password) {
+		// Return status 400 and error message.
+		return apperror.BadRequest(apperror.CodeWrongPassword, "The password is wrong")
+	}
+
+	// Soft delete user.
+	if err := p.Users.Delete(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Delete refresh token from Redis.
+	if err := p.revokeSessions(c.UserContext(), user.ID); err != nil {
+		// Return status 500 and Redis error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// currentUser method to get the signed in user by ID from JWT.
+func (d *Dependencies) currentUser(c *fiber.Ctx) (*models.User, error) {
+	// Get claims from JWT.
+	claims, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	// Get user by ID.
+	user, err := d.Users.GetByID(c.UserContext(), claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	} else if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return user, nil
+}
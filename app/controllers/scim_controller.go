@@ -0,0 +1,1081 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// scimContentType is the media type of SCIM requests and responses.
+const scimContentType = "application/scim+json"
+
+// scimMaxResults is the maximum number of resources returned by one SCIM list request.
+const scimMaxResults = 200
+
+// scimGroups are the organization roles exposed as SCIM groups.
+var scimGroups = []string{
+	repository.AdminRoleName,
+	repository.ModeratorRoleName,
+	repository.UserRoleName,
+}
+
+// scimUserAttributes are the SCIM user attributes supported in filters.
+var scimUserAttributes = map[string]utils.ScimFilterAttribute{
+	"id":              {Column: "CAST(users.id AS TEXT)"},
+	"username":        {Column: "users.email"},
+	"emails":          {Column: "users.email"},
+	"emails.value":    {Column: "users.email"},
+	"name.givenname":  {Column: "users.first_name"},
+	"name.familyname": {Column: "users.last_name"},
+	"externalid":      {Column: "memberships.external_id"},
+	"active":          {Column: "users.user_status", Boolean: true},
+}
+
+// scimGroupAttributes are the SCIM group attributes supported in filters.
+var scimGroupAttributes = map[string]utils.ScimFilterAttribute{
+	"id":          {Column: "role"},
+	"displayname": {Column: "role"},
+}
+
+// ScimController struct to describe SCIM 2.0 provisioning handlers of an organization.
+type ScimController struct {
+	*Dependencies
+}
+
+// CreateScimToken method to issue a new SCIM bearer token for an organization.
+// @Description Issue a new SCIM bearer token for an organization, the previous one stops working.
+// @Summary issue a new SCIM bearer token
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param Idempotency-Key header string false "Key to retry the request safely, its first response is replayed"
+// @Success 200 {object} models.Response{token=string}
+// @Failure default {object} apperror.Problem "problem details"
+// @Security ApiKeyAuth
+// @Router /api/v1/orgs/{id}/scim-token [post]
+func (o *OrganizationController) CreateScimToken(c *fiber.Ctx) error {
+	// Get organization administered by the current user.
+	org, _, err := o.adminOrganization(c)
+	if err != nil {
+		// Return status and error message.
+		return err
+	}
+
+	// Generate a new random token.
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	// Replace the organization's token, only its hash is stored.
+	if err := o.Organizations.ReplaceScimToken(c.UserContext(), &models.ScimToken{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		OrganizationID: org.ID,
+		TokenHash:      utils.HashToken(token),
+	}); err != nil {
+		// Return status 500 and database error.
+		return apperror.Internal(err)
+	}
+
+	// Return status 200 OK, the token is shown only once.
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"token":   token,
+	})
+}
+
+// ScimServiceProviderConfig method to describe supported SCIM features.
+// @Description Describe SCIM features supported by the service provider.
+// @Summary get SCIM service provider config
+// @Tags SCIM
+// @Produce application/scim+json
+// @Success 200 {object} object
+// @Failure default {object} models.ScimError "SCIM error"
+// @Router /scim/v2/ServiceProviderConfig [get]
+func ScimServiceProviderConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schemas":        []string{models.ScimServiceProviderConfigSchema},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": scimMaxResults},
+		"changePassword": fiber.Map{"supported": false},
+		"sort":           fiber.Map{"supported": false},
+		"etag":           fiber.Map{"supported": false},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the organization's SCIM bearer token",
+			"primary":     true,
+		}},
+		"meta": models.ScimMeta{ResourceType: "ServiceProviderConfig", Location: c.BaseURL() + "/scim/v2/ServiceProviderConfig"},
+	}, scimContentType)
+}
+
+// ScimSchemas method to describe supported SCIM resource schemas.
+// @Description Describe SCIM resource schemas supported by the service provider.
+// @Summary get SCIM schemas
+// @Tags SCIM
+// @Produce application/scim+json
+// @Success 200 {object} models.ScimListResponse
+// @Failure default {object} models.ScimError "SCIM error"
+// @Router /scim/v2/Schemas [get]
+func ScimSchemas(c *fiber.Ctx) error {
+	attribute := func(name, kind string, required bool, mutability string) fiber.Map {
+		return fiber.Map{
+			"name":        name,
+			"type":        kind,
+			"multiValued": false,
+			"required":    required,
+			"caseExact":   false,
+			"mutability":  mutability,
+			"returned":    "default",
+			"uniqueness":  "none",
+		}
+	}
+
+	schemas := []fiber.Map{
+		{
+			"schemas":     []string{models.ScimSchemaSchema},
+			"id":          models.ScimUserSchema,
+			"name":        "User",
+			"description": "User Account",
+			"attributes": []fiber.Map{
+				attribute("userName", "string", true, "readWrite"),
+				{
+					"name": "name", "type": "complex", "multiValued": false, "required": false, "mutability": "readWrite", "returned": "default",
+					"subAttributes": []fiber.Map{
+						attribute("givenName", "string", false, "readWrite"),
+						attribute("familyName", "string", false, "readWrite"),
+					},
+				},
+				{
+					"name": "emails", "type": "complex", "multiValued": true, "required": false, "mutability": "readWrite", "returned": "default",
+					"subAttributes": []fiber.Map{
+						attribute("value", "string", false, "readWrite"),
+						attribute("primary", "boolean", false, "readWrite"),
+					},
+				},
+				attribute("externalId", "string", false, "readWrite"),
+				attribute("active", "boolean", false, "readWrite"),
+				{
+					"name": "groups", "type": "complex", "multiValued": true, "required": false, "mutability": "readOnly", "returned": "default",
+					"subAttributes": []fiber.Map{
+						attribute("value", "string", false, "readOnly"),
+						attribute("display", "string", false, "readOnly"),
+					},
+				},
+			},
+			"meta": models.ScimMeta{ResourceType: "Schema", Location: c.BaseURL() + "/scim/v2/Schemas/" + models.ScimUserSchema},
+		},
+		{
+			"schemas":     []string{models.ScimSchemaSchema},
+			"id":          models.ScimGroupSchema,
+			"name":        "Group",
+			"description": "Organization role",
+			"attributes": []fiber.Map{
+				attribute("displayName", "string", true, "readOnly"),
+				{
+					"name": "members", "type": "complex", "multiValued": true, "required": false, "mutability": "readWrite", "returned": "default",
+					"subAttributes": []fiber.Map{
+						attribute("value", "string", false, "immutable"),
+						attribute("display", "string", false, "readOnly"),
+					},
+				},
+			},
+			"meta": models.ScimMeta{ResourceType: "Schema", Location: c.BaseURL() + "/scim/v2/Schemas/" + models.ScimGroupSchema},
+		},
+	}
+
+	return c.JSON(models.ScimListResponse{
+		Schemas:      []string{models.ScimListResponseSchema},
+		TotalResults: int64(len(schemas)),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	}, scimContentType)
+}
+
+// ScimGetUsers method to list users of the organization.
+// @Description List users of the organization, supports filter, startIndex and count.
+// @Summary list SCIM users
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based index of the first result"
+// @Param count query int false "Maximum number of results"
+// @Success 200 {object} models.ScimListResponse
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users [get]
+func (s *ScimController) ScimGetUsers(c *fiber.Ctx) error {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	// Get pagination from query.
+	startIndex, count := scimPagination(c)
+
+	query := repository.ScimUserQuery{
+		OrganizationID: orgID,
+		Offset:         startIndex - 1,
+		Count:          count,
+	}
+
+	// Apply filter from query.
+	if filter := c.Query("filter"); filter != "" {
+		where, args, err := utils.ScimFilterToSQL(filter, scimUserAttributes)
+		if err != nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		query.Where, query.Args = where, args
+	}
+
+	// Get requested page of users and count all matching ones.
+	users, total, err := s.Scim.ListUsers(c.UserContext(), query)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// Get memberships of found users.
+	memberships, err := s.scimMemberships(c.UserContext(), orgID, users)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	resources := make([]models.ScimUser, 0, len(users))
+	for i := range users {
+		resources = append(resources, scimUserResource(c, &users[i], memberships[users[i].ID]))
+	}
+
+	return c.JSON(models.ScimListResponse{
+		Schemas:      []string{models.ScimListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, scimContentType)
+}
+
+// ScimGetUser method to get one user of the organization by ID.
+// @Description Get one user of the organization by ID.
+// @Summary get SCIM user by ID
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.ScimUser
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users/{id} [get]
+func (s *ScimController) ScimGetUser(c *fiber.Ctx) error {
+	// Get organization user by ID.
+	user, membership, err := s.scimOrganizationUser(c)
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	return c.JSON(scimUserResource(c, user, membership), scimContentType)
+}
+
+// ScimCreateUser method to provision a new user into the organization.
+// @Description Provision a new account into the organization, an existing account with the same email can only join by invitation.
+// @Summary create SCIM user
+// @Tags SCIM
+// @Accept json,application/scim+json
+// @Produce application/scim+json
+// @Param user body models.ScimUser true "SCIM user"
+// @Success 201 {object} models.ScimUser
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users [post]
+func (s *ScimController) ScimCreateUser(c *fiber.Ctx) error {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	// Create a new SCIM user struct.
+	resource := &models.ScimUser{}
+
+	// Checking received data from JSON body.
+	if err := json.Unmarshal(c.Body(), resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	// Validate SCIM user fields.
+	if err := utils.NewValidator().Struct(resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", "userName must be a valid email address")
+	}
+
+	email := strings.ToLower(resource.UserName)
+
+	// Existing accounts belong to their owners, they are never linked without consent.
+	if _, err := s.Users.GetByEmail(c.UserContext(), email); err == nil {
+		return scimError(c, fiber.StatusConflict, "uniqueness", "User with this userName already exists")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// Provisioned users sign in by password reset or SSO, so set a random password.
+	secret, err := utils.GenerateRandomToken()
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	user := &models.User{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		Email:        email,
+		PasswordHash: utils.GeneratePassword(secret),
+		UserStatus:   1, // 0 == blocked, 1 == active
+		UserRole:     repository.UserRoleName,
+	}
+
+	membership := &models.Membership{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Role:           repository.UserRoleName,
+		Provisioned:    true,
+	}
+
+	// Set attributes from SCIM user.
+	scimApplyUser(user, membership, resource)
+
+	// Create user with membership.
+	err = s.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		if err := s.Users.Create(ctx, user); err != nil {
+			return err
+		}
+
+		return s.Organizations.CreateMembership(ctx, membership)
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return scimError(c, fiber.StatusConflict, "uniqueness", "User with this userName already exists")
+	} else if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// Return status 201 created.
+	created := scimUserResource(c, user, membership)
+	c.Location(created.Meta.Location)
+	return c.Status(fiber.StatusCreated).JSON(created, scimContentType)
+}
+
+// ScimReplaceUser method to replace attributes of a user of the organization.
+// @Description Replace attributes of a user of the organization.
+// @Summary replace SCIM user
+// @Tags SCIM
+// @Accept json,application/scim+json
+// @Produce application/scim+json
+// @Param id path string true "User ID"
+// @Param user body models.ScimUser true "SCIM user"
+// @Success 200 {object} models.ScimUser
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users/{id} [put]
+func (s *ScimController) ScimReplaceUser(c *fiber.Ctx) error {
+	// Get organization user by ID.
+	user, membership, err := s.scimOrganizationUser(c)
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	// Create a new SCIM user struct.
+	resource := &models.ScimUser{}
+
+	// Checking received data from JSON body.
+	if err := json.Unmarshal(c.Body(), resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	// Validate SCIM user fields.
+	if err := utils.NewValidator().Struct(resource); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", "userName must be a valid email address")
+	}
+
+	// Replace attributes and save.
+	account := *user
+	scimApplyUser(user, membership, resource)
+
+	return s.scimSaveUser(c, &account, user, membership)
+}
+
+// ScimPatchUser method to partially update a user of the organization.
+// @Description Partially update a user of the organization with SCIM PATCH operations.
+// @Summary patch SCIM user
+// @Tags SCIM
+// @Accept json,application/scim+json
+// @Produce application/scim+json
+// @Param id path string true "User ID"
+// @Param operations body models.ScimPatchOp true "SCIM patch operations"
+// @Success 200 {object} models.ScimUser
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users/{id} [patch]
+func (s *ScimController) ScimPatchUser(c *fiber.Ctx) error {
+	// Get organization user by ID.
+	user, membership, err := s.scimOrganizationUser(c)
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	// Get patch operations from JSON body.
+	patch, err := scimParsePatch(c)
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	account := *user
+	for _, operation := range patch.Operations {
+		op := strings.ToLower(operation.Op)
+
+		switch {
+		case op != "add" && op != "replace" && op != "remove":
+			return scimError(c, fiber.StatusBadRequest, "invalidSyntax", fmt.Sprintf("operation %q is not supported", operation.Op))
+		case operation.Path == "" && op != "remove":
+			// Without path the value holds attributes to set.
+			attributes := map[string]json.RawMessage{}
+			if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+			}
+			for path, value := range attributes {
+				if err := scimSetUserAttribute(user, membership, path, value); err != nil {
+					return scimError(c, fiber.StatusBadRequest, "invalidPath", err.Error())
+				}
+			}
+		case op == "remove":
+			if err := scimSetUserAttribute(user, membership, operation.Path, nil); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidPath", err.Error())
+			}
+		default:
+			if err := scimSetUserAttribute(user, membership, operation.Path, operation.Value); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidPath", err.Error())
+			}
+		}
+	}
+
+	// Validate the patched email, like userName of POST and PUT.
+	if err := utils.NewValidator().Var(user.Email, "required,email,lte=255"); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", "userName must be a valid email address")
+	}
+
+	return s.scimSaveUser(c, &account, user, membership)
+}
+
+// ScimDeleteUser method to deprovision a user from the organization.
+// @Description Remove a user from the organization, an account it provisioned is deactivated when it has no other organizations.
+// @Summary delete SCIM user
+// @Tags SCIM
+// @Param id path string true "User ID"
+// @Success 204 {string} status "ok"
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Users/{id} [delete]
+func (s *ScimController) ScimDeleteUser(c *fiber.Ctx) error {
+	// Get organization user by ID.
+	user, membership, err := s.scimOrganizationUser(c)
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	deactivated := false
+	err = s.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		// Remove user from the organization.
+		if err := s.Organizations.DeleteMembership(ctx, membership.ID); err != nil {
+			return err
+		}
+
+		// Accounts which joined by invitation belong to their owners, they only leave the organization.
+		if !membership.Provisioned {
+			return nil
+		}
+
+		// Deactivate account, if it doesn't belong to any other organization.
+		remaining, err := s.Organizations.ListMemberships(ctx, repository.MembershipFilter{UserID: user.ID})
+		if err != nil {
+			return err
+		}
+		if len(remaining) == 0 {
+			deactivated = true
+			user.UserStatus = 0 // 0 == blocked, 1 == active
+			user.UpdatedAt = time.Now()
+			return s.Users.Update(ctx, user)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// End sessions of the deactivated user.
+	if deactivated {
+		if err := s.revokeSessions(c.UserContext(), user.ID); err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+	}
+
+	// Return status 204 no content.
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ScimGetGroups method to list organization roles as SCIM groups.
+// @Description List organization roles as SCIM groups, supports filter on displayName.
+// @Summary list SCIM groups
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param filter query string false "SCIM filter"
+// @Param excludedAttributes query string false "Set to members to omit members"
+// @Success 200 {object} models.ScimListResponse
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Groups [get]
+func (s *ScimController) ScimGetGroups(c *fiber.Ctx) error {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	// Get pagination from query.
+	startIndex, count := scimPagination(c)
+
+	// Groups are fixed roles, so filter them in a VALUES table.
+	roles := scimGroups
+	if filter := c.Query("filter"); filter != "" {
+		where, args, err := utils.ScimFilterToSQL(filter, scimGroupAttributes)
+		if err != nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", err.Error())
+		}
+
+		roles, err = s.Scim.FilterRoles(c.UserContext(), scimGroups, where, args)
+		if err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+	}
+
+	// Cut requested page of groups.
+	total := len(roles)
+	page := []string{}
+	if startIndex-1 < total {
+		page = roles[startIndex-1 : min(total, startIndex-1+count)]
+	}
+
+	resources := make([]models.ScimGroup, 0, len(page))
+	for _, role := range page {
+		group, err := s.scimGroupResource(c, orgID, role)
+		if err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+		resources = append(resources, *group)
+	}
+
+	return c.JSON(models.ScimListResponse{
+		Schemas:      []string{models.ScimListResponseSchema},
+		TotalResults: int64(total),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, scimContentType)
+}
+
+// ScimGetGroup method to get one organization role as SCIM group.
+// @Description Get one organization role as SCIM group with its members.
+// @Summary get SCIM group by ID
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param id path string true "Group ID"
+// @Success 200 {object} models.ScimGroup
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Groups/{id} [get]
+func (s *ScimController) ScimGetGroup(c *fiber.Ctx) error {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	// Check the group exists.
+	role, err := utils.VerifyRole(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	group, err := s.scimGroupResource(c, orgID, role)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	return c.JSON(group, scimContentType)
+}
+
+// ScimPatchGroup method to change members of an organization role.
+// @Description Add, remove or replace members of an organization role with SCIM PATCH operations.
+// @Summary patch SCIM group
+// @Tags SCIM
+// @Accept json,application/scim+json
+// @Produce application/scim+json
+// @Param id path string true "Group ID"
+// @Param operations body models.ScimPatchOp true "SCIM patch operations"
+// @Success 200 {object} models.ScimGroup
+// @Failure default {object} models.ScimError "SCIM error"
+// @Security ApiKeyAuth
+// @Router /scim/v2/Groups/{id} [patch]
+func (s *ScimController) ScimPatchGroup(c *fiber.Ctx) error {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	// Check the group exists.
+	role, err := utils.VerifyRole(c.Params("id"))
+	if err != nil {
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	}
+
+	// Get patch operations from JSON body.
+	patch, err := scimParsePatch(c)
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", err.Error())
+	}
+
+	err = s.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		for _, operation := range patch.Operations {
+			op := strings.ToLower(operation.Op)
+			path := strings.ToLower(operation.Path)
+
+			// Only members of the group can be changed.
+			if !strings.HasPrefix(path, "members") {
+				return errScimInvalidPath
+			}
+
+			// Get member IDs from value or from path filter (members[value eq "id"]).
+			members := []models.ScimMember{}
+			if len(operation.Value) > 0 {
+				if err := json.Unmarshal(operation.Value, &members); err != nil {
+					return err
+				}
+			}
+			if _, filter, found := strings.Cut(operation.Path, "["); found {
+				where := strings.TrimSuffix(filter, "]")
+				fields := strings.Fields(where)
+				if len(fields) != 3 || !strings.EqualFold(fields[0], "value") || !strings.EqualFold(fields[1], "eq") {
+					return errScimInvalidPath
+				}
+				id, err := strconv.Unquote(fields[2])
+				if err != nil {
+					return errScimInvalidPath
+				}
+				members = append(members, models.ScimMember{Value: id})
+			}
+
+			userIDs := make([]string, 0, len(members))
+			for _, member := range members {
+				userIDs = append(userIDs, member.Value)
+			}
+
+			// Members leaving a group fall back to the default role.
+			switch op {
+			case "add":
+				if err := s.scimSetMembersRole(ctx, orgID, userIDs, role); err != nil {
+					return err
+				}
+			case "remove":
+				// Without members all of them are removed.
+				if len(userIDs) == 0 {
+					userIDs = nil
+				}
+				if err := s.scimResetGroup(ctx, orgID, role, userIDs); err != nil {
+					return err
+				}
+			case "replace":
+				if err := s.scimResetGroup(ctx, orgID, role, nil); err != nil {
+					return err
+				}
+				if err := s.scimSetMembersRole(ctx, orgID, userIDs, role); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("operation %q is not supported", operation.Op)
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, errScimInvalidPath) {
+		return scimError(c, fiber.StatusBadRequest, "invalidPath", err.Error())
+	}
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", err.Error())
+	}
+
+	group, err := s.scimGroupResource(c, orgID, role)
+	if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	return c.JSON(group, scimContentType)
+}
+
+// errScimInvalidPath is returned for a PATCH path which can't be applied.
+var errScimInvalidPath = errors.New("path is not supported")
+
+// scimError func for returning an error in SCIM format.
+// Details of server errors are logged, clients only get a generic message.
+func scimError(c *fiber.Ctx, status int, scimType, detail string) error {
+	if status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "scim request failed", "error", detail)
+		detail = "internal server error"
+	}
+
+	return c.Status(status).JSON(models.ScimError{
+		Schemas:  []string{models.ScimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}, scimContentType)
+}
+
+// scimPagination func for getting 1-based start index and page size from query.
+func scimPagination(c *fiber.Ctx) (int, int) {
+	startIndex := c.QueryInt("startIndex", 1)
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count := c.QueryInt("count", scimMaxResults)
+	if count < 0 {
+		count = 0
+	}
+	if count > scimMaxResults {
+		count = scimMaxResults
+	}
+
+	return startIndex, count
+}
+
+// scimParsePatch func for parsing SCIM PATCH request body.
+func scimParsePatch(c *fiber.Ctx) (*models.ScimPatchOp, error) {
+	patch := &models.ScimPatchOp{}
+	if err := json.Unmarshal(c.Body(), patch); err != nil {
+		return nil, err
+	}
+
+	if err := utils.NewValidator().Struct(patch); err != nil {
+		return nil, errors.New("at least one operation is required")
+	}
+
+	return patch, nil
+}
+
+// scimOrganizationUser method to get user of the SCIM organization by ID from path.
+func (s *ScimController) scimOrganizationUser(c *fiber.Ctx) (*models.User, *models.Membership, error) {
+	orgID := c.Locals("scim_org").(uuid.UUID)
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, nil, errors.New("user with the given ID is not found")
+	}
+
+	membership, err := s.Organizations.GetMembership(c.UserContext(), orgID, userID)
+	if err != nil {
+		return nil, nil, errors.New("user with the given ID is not found")
+	}
+
+	user, err := s.Users.GetByID(c.UserContext(), userID)
+	if err != nil {
+		return nil, nil, errors.New("user with the given ID is not found")
+	}
+
+	return user, membership, nil
+}
+
+// scimMemberships method to get organization memberships of the given users by user ID.
+func (s *ScimController) scimMemberships(ctx context.Context, orgID uuid.UUID, users []models.User) (map[uuid.UUID]*models.Membership, error) {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	memberships, err := s.Organizations.ListMemberships(ctx, repository.MembershipFilter{OrganizationID: orgID, UserIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uuid.UUID]*models.Membership, len(memberships))
+	for i := range memberships {
+		byUser[memberships[i].UserID] = &memberships[i]
+	}
+
+	return byUser, nil
+}
+
+// scimUserResource func for converting user with membership into SCIM user.
+func scimUserResource(c *fiber.Ctx, user *models.User, membership *models.Membership) models.ScimUser {
+	active := user.UserStatus == 1
+	created := user.CreatedAt
+	modified := user.UpdatedAt
+
+	resource := models.ScimUser{
+		Schemas:  []string{models.ScimUserSchema},
+		ID:       user.ID.String(),
+		UserName: user.Email,
+		Name: models.ScimName{
+			GivenName:  user.FirstName,
+			FamilyName: user.LastName,
+			Formatted:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		},
+		Emails: []models.ScimEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active: &active,
+		Meta: &models.ScimMeta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     c.BaseURL() + "/scim/v2/Users/" + user.ID.String(),
+		},
+	}
+
+	if membership != nil {
+		resource.ExternalID = membership.ExternalID
+		resource.Groups = []models.ScimMember{{
+			Value:   membership.Role,
+			Display: membership.Role,
+			Ref:     c.BaseURL() + "/scim/v2/Groups/" + membership.Role,
+		}}
+	}
+
+	return resource
+}
+
+// scimGroupResource method to convert organization role with its members into SCIM group.
+func (s *ScimController) scimGroupResource(c *fiber.Ctx, orgID uuid.UUID, role string) (*models.ScimGroup, error) {
+	group := &models.ScimGroup{
+		Schemas:     []string{models.ScimGroupSchema},
+		ID:          role,
+		DisplayName: role,
+		Members:     []models.ScimMember{},
+		Meta: &models.ScimMeta{
+			ResourceType: "Group",
+			Location:     c.BaseURL() + "/scim/v2/Groups/" + role,
+		},
+	}
+
+	// Members can be omitted for large organizations.
+	if strings.Contains(c.Query("excludedAttributes"), "members") {
+		return group, nil
+	}
+
+	users, err := s.Scim.RoleMembers(c.UserContext(), orgID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		group.Members = append(group.Members, models.ScimMember{
+			Value:   user.ID.String(),
+			Display: user.Email,
+			Ref:     c.BaseURL() + "/scim/v2/Users/" + user.ID.String(),
+		})
+	}
+
+	return group, nil
+}
+
+// scimApplyUser func for setting user and membership attributes from SCIM user.
+func scimApplyUser(user *models.User, membership *models.Membership, resource *models.ScimUser) {
+	user.Email = strings.ToLower(resource.UserName)
+	user.FirstName = resource.Name.GivenName
+	user.LastName = resource.Name.FamilyName
+	membership.ExternalID = resource.ExternalID
+
+	if resource.Active != nil {
+		user.UserStatus = 0 // 0 == blocked, 1 == active
+		if *resource.Active {
+			user.UserStatus = 1
+		}
+	}
+}
+
+// scimSetUserAttribute func for setting one user attribute from SCIM PATCH, nil value removes it.
+func scimSetUserAttribute(user *models.User, membership *models.Membership, path string, value json.RawMessage) error {
+	str := func() (string, error) {
+		var s string
+		if value == nil {
+			return "", nil
+		}
+		err := json.Unmarshal(value, &s)
+		return s, err
+	}
+
+	switch attribute := strings.ToLower(path); {
+	case attribute == "active":
+		// Some IdPs send booleans as strings ("False").
+		var active interface{}
+		if err := json.Unmarshal(value, &active); err != nil {
+			return err
+		}
+		switch v := active.(type) {
+		case bool:
+			user.UserStatus = map[bool]int{false: 0, true: 1}[v]
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			user.UserStatus = map[bool]int{false: 0, true: 1}[b]
+		default:
+			return errors.New("active must be a boolean")
+		}
+	case attribute == "username":
+		s, err := str()
+		if err != nil || s == "" {
+			return errors.New("userName must be a non-empty string")
+		}
+		user.Email = strings.ToLower(s)
+	case attribute == "name.givenname":
+		s, err := str()
+		if err != nil {
+			return err
+		}
+		user.FirstName = s
+	case attribute == "name.familyname":
+		s, err := str()
+		if err != nil {
+			return err
+		}
+		user.LastName = s
+	case attribute == "name":
+		name := models.ScimName{}
+		if value != nil {
+			if err := json.Unmarshal(value, &name); err != nil {
+				return err
+			}
+		}
+		user.FirstName, user.LastName = name.GivenName, name.FamilyName
+	case attribute == "externalid":
+		s, err := str()
+		if err != nil {
+			return err
+		}
+		membership.ExternalID = s
+	case strings.HasPrefix(attribute, "emails"):
+		// Email is the login identifier, so it can't be removed.
+		if value == nil {
+			return errors.New("emails can't be removed")
+		}
+		if s, err := str(); err == nil && s != "" {
+			user.Email = strings.ToLower(s)
+			return nil
+		}
+		emails := []models.ScimEmail{}
+		if err := json.Unmarshal(value, &emails); err != nil || len(emails) == 0 {
+			return errors.New("emails must contain at least one email")
+		}
+		user.Email = strings.ToLower(emails[0].Value)
+		for _, email := range emails {
+			if email.Primary {
+				user.Email = strings.ToLower(email.Value)
+			}
+		}
+	default:
+		return fmt.Errorf("attribute %q is not supported", path)
+	}
+
+	return nil
+}
+
+// scimSaveUser method to save user with membership and return the SCIM user.
+// The account is only changed, if the organization provisioned it, others can only change the membership.
+func (s *ScimController) scimSaveUser(c *fiber.Ctx, account, user *models.User, membership *models.Membership) error {
+	changed := account.Email != user.Email || account.FirstName != user.FirstName ||
+		account.LastName != user.LastName || account.UserStatus != user.UserStatus
+	if !changed {
+		// Save membership changes only.
+		if err := s.Organizations.UpdateMembership(c.UserContext(), membership); err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+
+		return c.JSON(scimUserResource(c, user, membership), scimContentType)
+	}
+
+	// Accounts which joined by invitation belong to their owners.
+	if !membership.Provisioned {
+		return scimError(c, fiber.StatusForbidden, "", "User account is not managed by this organization, only externalId and groups can be changed")
+	}
+
+	// Check the email is not used by another account.
+	if s.emailTaken(c.UserContext(), user.Email, user.ID) {
+		return scimError(c, fiber.StatusConflict, "uniqueness", "User with this userName already exists")
+	}
+
+	user.UpdatedAt = time.Now()
+	err := s.Tx.Transaction(c.UserContext(), func(ctx context.Context) error {
+		if err := s.Users.Update(ctx, user); err != nil {
+			return err
+		}
+
+		return s.Organizations.UpdateMembership(ctx, membership)
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return scimError(c, fiber.StatusConflict, "uniqueness", "User with this userName already exists")
+	} else if err != nil {
+		return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+	}
+
+	// End sessions of a deactivated user.
+	if user.UserStatus != 1 {
+		if err := s.revokeSessions(c.UserContext(), user.ID); err != nil {
+			return scimError(c, fiber.StatusInternalServerError, "", err.Error())
+		}
+	}
+
+	return c.JSON(scimUserResource(c, user, membership), scimContentType)
+}
+
+// scimSetMembersRole method to move organization members into the role.
+func (s *ScimController) scimSetMembersRole(ctx context.Context, orgID uuid.UUID, userIDs []string, role string) error {
+	for _, id := range userIDs {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("member %q is not valid", id)
+		}
+
+		membership, err := s.Organizations.GetMembership(ctx, orgID, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("member %q is not found", id)
+		} else if err != nil {
+			return err
+		}
+
+		membership.Role = role
+		if err := s.Organizations.UpdateMembership(ctx, membership); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scimResetGroup method to move members out of the role into the default one, nil moves all members.
+func (s *ScimController) scimResetGroup(ctx context.Context, orgID uuid.UUID, role string, userIDs []string) error {
+	members, err := s.Organizations.ListMemberships(ctx, repository.MembershipFilter{OrganizationID: orgID, Role: role})
+	if err != nil {
+		return err
+	}
+
+	for i := range members {
+		if userIDs != nil && !slices.Contains(userIDs, members[i].UserID.String()) {
+			continue
+		}
+		members[i].Role = repository.UserRoleName
+		if err := s.Organizations.UpdateMembership(ctx, &members[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
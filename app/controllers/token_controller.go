@@ -1,28 +1,34 @@
 package controllers
 
 import (
-	"context"
+	"crypto/subtle"
+	"errors"
 	"time"
 
 	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/repository"
 	"github.com/Figbase/api/pkg/utils"
-	"github.com/Figbase/api/platform/cache"
-	"github.com/Figbase/api/platform/database"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // RenewTokens method for renew access and refresh tokens.
-// @Description Renew access and refresh tokens.
+// @Description Renew access and refresh tokens, the refresh token must be the one of the current session. In the cookie session mode the refresh token is read from the refresh_token cookie when the body has none, and the new one is set in it.
 // @Summary renew access and refresh tokens
 // @Tags Token
 // @Accept json
 // @Produce json
-// @Param refresh_token body string true "Refresh token"
-// @Success 200 {string} status "ok"
+// @Param refresh_token body string false "Refresh token, required unless sent in the refresh_token cookie"
+// @Success 200 {object} models.Response{tokens=object{access=string,refresh=string}}
+// @Failure default {object} apperror.Problem "problem details"
 // @Security ApiKeyAuth
-// @Router /v1/token/renew [post]
-func RenewTokens(c *fiber.Ctx) error {
+// @Router /api/v1/token/renew [post]
+func (a *AuthController) RenewTokens(c *fiber.Ctx) (err error) {
+	// Count and audit the attempt by response status.
+	attempt := a.newAuthAttempt("renew")
+	defer func() { attempt.record(c, err) }()
+
 	// Get now time.
 	now := time.Now().Unix()
 
@@ -30,119 +36,86 @@ func RenewTokens(c *fiber.Ctx) error {
 	claims, err := utils.ExtractTokenMetadata(c)
 	if err != nil {
 		// Return status 500 and JWT parse error.
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
-	}
-
-	// Set expiration time from JWT data of current user.
-	expiresAccessToken := claims.Expires
-
-	// Checking, if now time greather than Access token expiration time.
-	if now > expiresAccessToken {
-		// Return status 401 and unauthorized error message.
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "unauthorized, check expiration time of your token",
-		})
+		return apperror.Internal(err)
 	}
+	attempt.userID = claims.UserID
 
 	// Create a new renew refresh token struct.
 	renew := &models.Renew{}
 
-	// Checking received data from JSON body.
-	if err := c.BodyParser(renew); err != nil {
-		// Return, if JSON data is not correct.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// Checking received data from JSON body, it may be empty in the cookie session mode.
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(renew); err != nil {
+			// Return, if JSON data is not correct.
+			return apperror.InvalidBody(err)
+		}
+	}
+
+	// Read refresh token from the cookie, if the body has none.
+	if renew.RefreshToken == "" && a.Session.Mode == utils.SessionModeCookie {
+		renew.RefreshToken = c.Cookies(utils.RefreshTokenCookie)
 	}
 
 	// Set expiration time from Refresh token of current user.
 	expiresRefreshToken, err := utils.ParseRefreshToken(renew.RefreshToken)
 	if err != nil {
 		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		return apperror.BadRequest(apperror.CodeTokenInvalid, err.Error())
 	}
 
 	// Checking, if now time greather than Refresh token expiration time.
-	if now < expiresRefreshToken {
-		// Define user ID.
-		userID := claims.UserID
-
-		// Create a new user struct.
-		user := &models.User{}
-
-		// Get user by ID.
-		result := database.DB.Db.Where("email = ?", userID).First(&user)
-
-		// Check if the user was not found.
-		if result.Error != nil {
-			// Return, if user not found.
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status":  "error",
-				"message": "user with the given ID is not found",
-			})
-		}
+	if now >= expiresRefreshToken {
+		// Return status 401 and unauthorized error message.
+		return apperror.Unauthorized(apperror.CodeSessionEnded, "unauthorized, your session was ended earlier")
+	}
 
-		// Get role credentials from founded user.
-		credentials, err := utils.GetCredentialsByRole(user.UserRole)
-		if err != nil {
-			// Return status 400 and error message.
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		}
+	// Define user ID.
+	userID := claims.UserID
 
-		// Generate JWT Access & Refresh tokens.
-		tokens, err := utils.GenerateNewTokens(userID.String(), credentials)
-		if err != nil {
-			// Return status 500 and token generation error.
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		}
+	// Compare refresh token with the one of the stored session, signed out users have none.
+	session, err := a.Sessions.Get(c.UserContext(), userID)
+	if errors.Is(err, repository.ErrNotFound) ||
+		err == nil && subtle.ConstantTimeCompare([]byte(session), []byte(utils.HashToken(renew.RefreshToken))) != 1 {
+		// Return status 401 and unauthorized error message.
+		return apperror.Unauthorized(apperror.CodeSessionEnded, "unauthorized, your session was ended earlier")
+	} else if err != nil {
+		// Return status 500 and session store error.
+		return apperror.Internal(err)
+	}
 
-		// Create a new Redis connection.
-		connRedis, err := cache.RedisConnection()
-		if err != nil {
-			// Return status 500 and Redis connection error.
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": err.Error(),
-			})
-		}
+	// Get user by ID.
+	user, err := a.Users.GetByID(c.UserContext(), userID)
 
-		// Save refresh token to Redis.
-		errRedis := connRedis.Set(context.Background(), userID.String(), tokens.Refresh, 0).Err()
-		if errRedis != nil {
-			// Return status 500 and Redis connection error.
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": errRedis.Error(),
-			})
-		}
+	// Check if the user was not found.
+	if err != nil {
+		// Return, if user not found.
+		return apperror.NotFound(apperror.CodeUserNotFound, "user with the given ID is not found")
+	}
 
-		return c.JSON(fiber.Map{
-			"status":  "success",
-			"message": nil,
-			"tokens": fiber.Map{
-				"access":  tokens.Access,
-				"refresh": tokens.Refresh,
-			},
-		})
-	} else {
-		// Return status 401 and unauthorized error message.
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "unauthorized, your session was ended earlier",
-		})
+	// Check if the user account was deactivated meanwhile.
+	if user.UserStatus != 1 {
+		attempt.metadata["reason"] = "blocked"
+		// Return status 403 and error message.
+		return apperror.Forbidden(apperror.CodeAccountBlocked, "The user account is blocked")
 	}
+
+	// Generate JWT Access & Refresh tokens and replace the session.
+	tokens, err := a.issueTokens(c.UserContext(), user)
+	if err != nil {
+		// Return status 500 and token generation error.
+		return apperror.Internal(err)
+	}
+
+	// Send refresh token in the configured transport.
+	body, err := a.sendTokens(c, tokens)
+	if err != nil {
+		// Return status 500 and error message.
+		return apperror.Internal(err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": nil,
+		"tokens":  body,
+	})
 }
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/tracing"
+)
+
+// hashPassword func for hashing a password inside a trace span, bcrypt is slow by design.
+func hashPassword(ctx context.Context, password string) string {
+	_, span := tracing.Start(ctx, "password.hash")
+	defer span.End()
+
+	return utils.GeneratePassword(password)
+}
+
+// comparePassword func for checking a password inside a trace span.
+func comparePassword(ctx context.Context, hash, password string) bool {
+	_, span := tracing.Start(ctx, "password.compare")
+	defer span.End()
+
+	return utils.ComparePasswords(hash, password)
+}
+
+// generateTokens func for signing a new pair of tokens inside a trace span.
+func generateTokens(ctx context.Context, userID string, credentials []string) (*utils.Tokens, error) {
+	_, span := tracing.Start(ctx, "token.generate")
+	defer span.End()
+
+	tokens, err := utils.GenerateNewTokens(userID, credentials)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return tokens, err
+}
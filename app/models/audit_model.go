@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AuditOutcomeSuccess const for an action which succeeded.
+	AuditOutcomeSuccess string = "success"
+
+	// AuditOutcomeFailure const for an action which was refused or failed.
+	AuditOutcomeFailure string = "failure"
+)
+
+// AuditEvent struct to describe one entry of the append-only security audit log.
+// Each entry holds the hash of the previous one, so removed or changed entries break the chain.
+type AuditEvent struct {
+	ID        int64         `db:"id" json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+	ActorID   *uuid.UUID    `db:"actor_id" json:"actor_id,omitempty" gorm:"type:uuid"`
+	TargetID  *uuid.UUID    `db:"target_id" json:"target_id,omitempty" gorm:"type:uuid"`
+	Action    string        `db:"action" json:"action"`
+	Outcome   string        `db:"outcome" json:"outcome"`
+	IP        string        `db:"ip" json:"ip"`
+	UserAgent string        `db:"user_agent" json:"user_agent"`
+	Metadata  AuditMetadata `db:"metadata" json:"metadata" gorm:"type:jsonb"`
+	PrevHash  string        `db:"prev_hash" json:"prev_hash"`
+	Hash      string        `db:"hash" json:"hash"`
+}
+
+// AuditMetadata type to describe extra details of an audit event, stored as JSON.
+type AuditMetadata map[string]string
+
+// Value method to store metadata as JSON.
+func (m AuditMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(m)
+	return string(data), err
+}
+
+// Scan method to read metadata from JSON.
+func (m *AuditMetadata) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*m = AuditMetadata{}
+		return nil
+	default:
+		return errors.New("audit metadata must be JSON")
+	}
+
+	return json.Unmarshal(data, m)
+}
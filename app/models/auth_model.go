@@ -9,6 +9,8 @@ type SignUp struct {
 	LastName  string `json:"lastname" validate:"required,lte=255"`
 	Email     string `json:"email" validate:"required,email,lte=255"`
 	Password  string `json:"password" validate:"required,lte=255"`
+	// InviteToken is set when signing up from an organization invitation.
+	InviteToken string `json:"invite_token"`
 	// UserRole string `json:"user_role" validate:"required,lte=25"`
 }
 
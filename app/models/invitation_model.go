@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// InvitationStatusPending const for an invitation waiting to be accepted.
+	InvitationStatusPending string = "pending"
+
+	// InvitationStatusAccepted const for an accepted invitation.
+	InvitationStatusAccepted string = "accepted"
+
+	// InvitationStatusRevoked const for an invitation revoked by an admin.
+	InvitationStatusRevoked string = "revoked"
+)
+
+// Invitation struct to describe an invitation to join an organization.
+type Invitation struct {
+	ID             uuid.UUID  `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id" gorm:"type:uuid;index"`
+	InvitedBy      uuid.UUID  `db:"invited_by" json:"invited_by" gorm:"type:uuid"`
+	Email          string     `db:"email" json:"email" gorm:"index"`
+	Role           string     `db:"role" json:"role"`
+	Status         string     `db:"status" json:"status"`
+	TokenHash      string     `db:"token_hash" json:"-"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at" json:"accepted_at,omitempty"`
+}
+
+// CreateInvitation struct to describe inviting a new member.
+type CreateInvitation struct {
+	Email string `json:"email" validate:"required,email,lte=255"`
+	Role  string `json:"role" validate:"required,lte=25"`
+}
+
+// AcceptInvitation struct to describe accepting an invitation.
+type AcceptInvitation struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization struct to describe Organization object.
+type Organization struct {
+	ID        uuid.UUID `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Name      string    `db:"name" json:"name" validate:"required,lte=255"`
+	OwnerID   uuid.UUID `db:"owner_id" json:"owner_id" gorm:"type:uuid"`
+}
+
+// Membership struct to describe a user's membership in an organization.
+type Membership struct {
+	ID             uuid.UUID `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id" gorm:"type:uuid;uniqueIndex:idx_memberships_org_user"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id" gorm:"type:uuid;uniqueIndex:idx_memberships_org_user"`
+	Role           string    `db:"role" json:"role" validate:"required,lte=25"`
+	ExternalID     string    `db:"external_id" json:"external_id,omitempty"`
+	Provisioned    bool      `db:"provisioned" json:"-"` // the account was created by SCIM of the organization, which manages it
+}
+
+// CreateOrganization struct to describe creating a new organization.
+type CreateOrganization struct {
+	Name string `json:"name" validate:"required,lte=255"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DataExportStatusPending const for an export being prepared.
+	DataExportStatusPending string = "pending"
+
+	// DataExportStatusReady const for an export ready to download.
+	DataExportStatusReady string = "ready"
+
+	// DataExportStatusFailed const for an export which could not be prepared.
+	DataExportStatusFailed string = "failed"
+)
+
+// DataExport struct to describe a personal data export of a user.
+type DataExport struct {
+	ID          uuid.UUID  `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UserID      uuid.UUID  `db:"user_id" json:"user_id" gorm:"type:uuid;index"`
+	Status      string     `db:"status" json:"status"`
+	Error       string     `db:"error" json:"error,omitempty"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	Archive     []byte     `db:"archive" json:"-" gorm:"type:bytea"`
+}
+
+// ErasureTombstone struct to describe proof of an erased user.
+// It keeps no personal data, an email hash could be reversed by hashing known emails.
+type ErasureTombstone struct {
+	ID          uuid.UUID `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `db:"user_id" json:"user_id" gorm:"type:uuid;uniqueIndex"`
+	RequestedBy uuid.UUID `db:"requested_by" json:"requested_by" gorm:"type:uuid"`
+	ErasedAt    time.Time `db:"erased_at" json:"erased_at"`
+}
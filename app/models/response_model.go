@@ -0,0 +1,8 @@
+package models
+
+// Response struct to describe the envelope of successful JSON responses.
+// Handlers add their data next to it, annotated like models.Response{user=models.User}.
+type Response struct {
+	Status  string  `json:"status" validate:"required"`
+	Message *string `json:"message"`
+}
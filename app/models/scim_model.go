@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ScimUserSchema const for SCIM core user schema URN.
+	ScimUserSchema string = "urn:ietf:params:scim:schemas:core:2.0:User"
+
+	// ScimGroupSchema const for SCIM core group schema URN.
+	ScimGroupSchema string = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+	// ScimListResponseSchema const for SCIM list response message URN.
+	ScimListResponseSchema string = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+
+	// ScimPatchOpSchema const for SCIM patch operation message URN.
+	ScimPatchOpSchema string = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+
+	// ScimErrorSchema const for SCIM error message URN.
+	ScimErrorSchema string = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// ScimServiceProviderConfigSchema const for SCIM service provider config URN.
+	ScimServiceProviderConfigSchema string = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	// ScimSchemaSchema const for SCIM schema resource URN.
+	ScimSchemaSchema string = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// ScimToken struct to describe a SCIM bearer token of an organization.
+type ScimToken struct {
+	ID             uuid.UUID  `db:"id" json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id" gorm:"type:uuid;uniqueIndex"`
+	TokenHash      string     `db:"token_hash" json:"-" gorm:"uniqueIndex"`
+	LastUsedAt     *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+}
+
+// ScimMeta struct to describe SCIM resource metadata.
+type ScimMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// ScimName struct to describe SCIM user name.
+type ScimName struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	Formatted  string `json:"formatted,omitempty"`
+}
+
+// ScimEmail struct to describe SCIM user email.
+type ScimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// ScimMember struct to describe SCIM group member or user group.
+type ScimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// ScimUser struct to describe SCIM user resource.
+type ScimUser struct {
+	Schemas    []string     `json:"schemas"`
+	ID         string       `json:"id,omitempty"`
+	ExternalID string       `json:"externalId,omitempty"`
+	UserName   string       `json:"userName" validate:"required,email,lte=255"`
+	Name       ScimName     `json:"name"`
+	Emails     []ScimEmail  `json:"emails,omitempty"`
+	Active     *bool        `json:"active,omitempty"`
+	Groups     []ScimMember `json:"groups,omitempty"`
+	Meta       *ScimMeta    `json:"meta,omitempty"`
+}
+
+// ScimGroup struct to describe SCIM group resource.
+type ScimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id"`
+	DisplayName string       `json:"displayName"`
+	Members     []ScimMember `json:"members"`
+	Meta        *ScimMeta    `json:"meta,omitempty"`
+}
+
+// ScimListResponse struct to describe SCIM list response.
+type ScimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// ScimPatchOperation struct to describe a single SCIM patch operation.
+type ScimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ScimPatchOp struct to describe SCIM patch request.
+type ScimPatchOp struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []ScimPatchOperation `json:"Operations" validate:"required,min=1"`
+}
+
+// ScimError struct to describe SCIM error response.
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
@@ -9,15 +9,58 @@ import (
 )
 
 // User struct to describe User object.
+// Email is unique case-insensitively among users which are not deleted.
 type User struct {
-	gorm.Model
-	ID           uuid.UUID `db:"id" json:"id" validate:"required,uuid"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
-	FirstName    string    `db:"firstname" json:"firstname" validate:"required,lte=255"`
-	LastName     string    `db:"lastname" json:"lastname" validate:"required,lte=255"`
-	Email        string    `db:"email" json:"email" validate:"required,email,lte=255"`
-	PasswordHash string    `db:"password_hash" json:"password_hash,omitempty" validate:"required,lte=255"`
-	UserStatus   int       `db:"user_status" json:"user_status" validate:"required,len=1"`
-	UserRole     string    `db:"user_role" json:"user_role" validate:"required,lte=25"`
+	ID           uuid.UUID      `db:"id" json:"id" gorm:"type:uuid;primaryKey" validate:"required,uuid"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `db:"deleted_at" json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
+	FirstName    string         `db:"first_name" json:"firstname" validate:"required,lte=255"`
+	LastName     string         `db:"last_name" json:"lastname" validate:"required,lte=255"`
+	Email        string         `db:"email" json:"email" validate:"required,email,lte=255"`
+	PasswordHash string         `db:"password_hash" json:"password_hash,omitempty" validate:"required,lte=255"`
+	UserStatus   int            `db:"user_status" json:"user_status" validate:"required,len=1"`
+	UserRole     string         `db:"user_role" json:"user_role" validate:"required,lte=25"`
+	Timezone     string         `db:"timezone" json:"timezone" validate:"omitempty,timezone"`
+	Locale       string         `db:"locale" json:"locale" validate:"omitempty,bcp47_language_tag"`
+	AvatarURL    string         `db:"avatar_url" json:"avatar_url" validate:"omitempty,http_url,lte=2048"`
+}
+
+// UpdateUser struct to describe updating a user by an admin.
+type UpdateUser struct {
+	FirstName  *string `json:"firstname" validate:"omitempty,lte=255"`
+	LastName   *string `json:"lastname" validate:"omitempty,lte=255"`
+	UserRole   *string `json:"user_role" validate:"omitempty,lte=25"`
+	UserStatus *int    `json:"user_status" validate:"omitempty,oneof=0 1"`
+}
+
+// UpdateProfile struct to describe updating own profile by a user.
+type UpdateProfile struct {
+	FirstName *string `json:"firstname" validate:"omitempty,lte=255"`
+	LastName  *string `json:"lastname" validate:"omitempty,lte=255"`
+	Timezone  *string `json:"timezone" validate:"omitempty,timezone"`
+	Locale    *string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	AvatarURL *string `json:"avatar_url" validate:"omitempty,http_url,lte=2048"`
+}
+
+// CloseAccount struct to describe closing own account by a user.
+type CloseAccount struct {
+	Password string `json:"password" validate:"required,lte=255"`
+}
+
+// ChangeEmail struct to describe requesting a new email by a user.
+type ChangeEmail struct {
+	Email    string `json:"email" validate:"required,email,lte=255"`
+	Password string `json:"password" validate:"required,lte=255"`
+}
+
+// ConfirmEmail struct to describe confirming a new email.
+type ConfirmEmail struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// EmailChange struct to describe a pending email change stored in Redis.
+type EmailChange struct {
+	TokenHash string `json:"token_hash"`
+	Email     string `json:"email"`
 }
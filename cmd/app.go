@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/Figbase/api/app/controllers"
+	"github.com/Figbase/api/docs"
+	"github.com/Figbase/api/pkg/middleware"
+	"github.com/Figbase/api/pkg/routes"
+	"github.com/Figbase/api/platform/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newApp func for creating the Fiber app with middleware and routes.
+func newApp(cfg *config.Config, handlers *controllers.Controllers, health *controllers.HealthController) *fiber.App {
+	// Define a new Fiber app with server limits from config.
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		BodyLimit:    cfg.Server.BodyLimit,
+
+		// Client IP from the load balancer, used by logs and rate limits.
+		ProxyHeader:             cfg.Server.ProxyHeader,
+		EnableTrustedProxyCheck: len(cfg.Server.TrustedProxies) > 0,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		EnableIPValidation:      true,
+
+		// Write errors of all routes as problem details.
+		ErrorHandler: middleware.ErrorHandler,
+	})
+
+	// Middlewares.
+	middleware.FiberMiddleware(app, cfg)                                             // Register Fiber's middleware for app.
+	app.Use(middleware.OpenAPIValidator(docs.OpenAPI, cfg.Server.ValidateResponses)) // Validate requests against the OpenAPI document.
+
+	// Routes.
+	routes.HealthRoutes(app, health)        // Register liveness and readiness probes.
+	routes.MetricsRoute(app)                // Register Prometheus metrics route.
+	routes.DocsRoutes(app)                  // Register OpenAPI document and Swagger UI.
+	routes.PublicRoutes(app, handlers.Auth) // Register public routes for app.
+	routes.PrivateRoutes(app, handlers)     // Register private routes for app.
+	routes.ScimRoutes(app, handlers.Scim)   // Register SCIM provisioning routes for app.
+	routes.NotFoundRoute(app)               // Register route for 404 Error.
+
+	return app
+}
@@ -0,0 +1,2116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Figbase/api/app/controllers"
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/docs"
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/middleware"
+	"github.com/Figbase/api/pkg/openapi"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/logging"
+	"github.com/Figbase/api/platform/tracing"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fiberPathParam matches a parameter of a Fiber route, like :id.
+var fiberPathParam = regexp.MustCompile(`:(\w+)`)
+
+// testSecretKey signs access tokens in tests.
+const testSecretKey = "test-secret-key"
+
+// testServer struct to describe the app wired with in-memory stores.
+type testServer struct {
+	app           *fiber.App
+	users         *repository.MemoryUserRepository
+	organizations *repository.MemoryOrganizationRepository
+	invitations   *repository.MemoryInvitationRepository
+	privacy       *repository.MemoryPrivacyRepository
+	sessions      *repository.MemorySessionStore
+	tokens        *repository.MemoryTokenStore
+	idempotency   *middleware.MemoryIdempotencyStore
+	health        *controllers.HealthController
+	logs          *logBuffer
+	redisErr      error
+}
+
+// logBuffer struct to collect log output of the app, safe for concurrent writes.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write method to append log output.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String method to get all log output.
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// records method to decode JSON log lines with the given message.
+func (b *logBuffer) records(t *testing.T, msg string) []map[string]interface{} {
+	t.Helper()
+
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		if record["msg"] == msg {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// newTestServer func for booting the app like main does, with in-memory stand-ins for Postgres and Redis.
+// Options change the config before the app is created.
+func newTestServer(t *testing.T, options ...func(cfg *config.Config)) *testServer {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.JWT.SecretKey = testSecretKey
+	cfg.JWT.SecretKeyExpireMinutes = 15
+	cfg.JWT.RefreshKey = "test-refresh-key"
+	cfg.JWT.RefreshKeyExpireHours = 24
+	cfg.JWT.InviteKey = "test-invite-key"
+	cfg.Server.ValidateResponses = true
+	for _, option := range options {
+		option(cfg)
+	}
+
+	users := repository.NewMemoryUserRepository()
+	sessions := repository.NewMemorySessionStore()
+
+	utils.ConfigureTokens(&cfg.JWT)
+	middleware.ConfigureRateLimit(&cfg.RateLimit, middleware.NewMemoryRateLimiter())
+	idempotency := middleware.NewMemoryIdempotencyStore()
+	middleware.ConfigureIdempotency(&cfg.Idempotency, idempotency)
+
+	s := &testServer{
+		users:         users,
+		organizations: repository.NewMemoryOrganizationRepository(),
+		invitations:   repository.NewMemoryInvitationRepository(),
+		privacy:       repository.NewMemoryPrivacyRepository(),
+		sessions:      sessions,
+		tokens:        repository.NewMemoryTokenStore(),
+		idempotency:   idempotency,
+		logs:          &logBuffer{},
+	}
+	cfg.Log.Level = "debug"
+	logging.Setup(&cfg.Log, s.logs)
+
+	s.health = controllers.NewHealthController(map[string]controllers.HealthCheck{
+		"postgres": func(context.Context) error { return nil },
+		"redis":    func(context.Context) error { return s.redisErr },
+	})
+	s.app = newApp(cfg, controllers.New(&controllers.Dependencies{
+		App:           &cfg.App,
+		Session:       &cfg.Session,
+		Tx:            repository.NewMemoryTransactor(),
+		Users:         users,
+		Organizations: s.organizations,
+		Invitations:   s.invitations,
+		Privacy:       s.privacy,
+		Sessions:      sessions,
+		Tokens:        s.tokens,
+		Audit:         repository.NewMemoryAuditStore(),
+	}), s.health)
+
+	return s
+}
+
+// do method to send a request and decode the JSON response body.
+func (s *testServer) do(t *testing.T, method, path, body, token string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	result := map[string]interface{}{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &result); err != nil {
+			t.Fatalf("%s %s: body is not JSON: %s", method, path, raw)
+		}
+	}
+
+	return resp.StatusCode, result
+}
+
+// signUp method to register a user and return the access and refresh tokens.
+func (s *testServer) signUp(t *testing.T, email, password string) (string, string) {
+	t.Helper()
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", `{
+		"firstname": "Ada",
+		"lastname": "Lovelace",
+		"email": "`+email+`",
+		"password": "`+password+`"
+	}`, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("sign up: status %d, body %v", status, body)
+	}
+
+	return tokensOf(t, body)
+}
+
+// invite method to store a pending invitation to a new organization and return its invite token.
+func (s *testServer) invite(t *testing.T, email, role string) (uuid.UUID, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	org := &models.Organization{ID: uuid.New(), CreatedAt: time.Now(), Name: "Analytical Engines", OwnerID: uuid.New()}
+	if err := s.organizations.Create(ctx, org); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	invitation := &models.Invitation{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		OrganizationID: org.ID,
+		InvitedBy:      org.OwnerID,
+		Email:          email,
+		Role:           role,
+		Status:         models.InvitationStatusPending,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	token, err := utils.GenerateInviteToken(invitation.ID.String(), invitation.ExpiresAt)
+	if err != nil {
+		t.Fatalf("generate invite token: %v", err)
+	}
+	invitation.TokenHash = utils.HashToken(token)
+	if err := s.invitations.Save(ctx, invitation); err != nil {
+		t.Fatalf("save invitation: %v", err)
+	}
+
+	return org.ID, token
+}
+
+// inviteToken method to sign an invite token of the stored invitation, like the emailed one.
+func (s *testServer) inviteToken(t *testing.T, invitationID uuid.UUID) string {
+	t.Helper()
+
+	invitation, err := s.invitations.GetByID(context.Background(), invitationID)
+	if err != nil {
+		t.Fatalf("get invitation: %v", err)
+	}
+	token, err := utils.GenerateInviteToken(invitation.ID.String(), invitation.ExpiresAt)
+	if err != nil {
+		t.Fatalf("generate invite token: %v", err)
+	}
+	if utils.HashToken(token) != invitation.TokenHash {
+		t.Fatalf("invite token of %s is not the emailed one", invitationID)
+	}
+
+	return token
+}
+
+// emailChange method to store a pending email change of the user and return its confirmation token.
+func (s *testServer) emailChange(t *testing.T, userID uuid.UUID, email string, ttl time.Duration) string {
+	t.Helper()
+
+	token := userID.String() + "." + uuid.NewString()
+	pending, _ := json.Marshal(&models.EmailChange{TokenHash: utils.HashToken(token), Email: email})
+	if err := s.tokens.Save(context.Background(), "email_change:"+userID.String(), pending, ttl); err != nil {
+		t.Fatalf("save email change: %v", err)
+	}
+
+	return token
+}
+
+// admin method to store an active admin and return an access token with its credentials.
+func (s *testServer) admin(t *testing.T, email string) (*models.User, string) {
+	t.Helper()
+
+	user := &models.User{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		Email:      email,
+		UserStatus: 1,
+		UserRole:   repository.AdminRoleName,
+	}
+	if err := s.users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	credentials, err := utils.GetCredentialsByRole(user.UserRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := utils.GenerateNewTokens(user.ID.String(), credentials)
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	return user, tokens.Access
+}
+
+// tokensOf func for getting access and refresh tokens from a response body.
+func tokensOf(t *testing.T, body map[string]interface{}) (string, string) {
+	t.Helper()
+
+	tokens, ok := body["tokens"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("response has no tokens: %v", body)
+	}
+	access, _ := tokens["access"].(string)
+	refresh, _ := tokens["refresh"].(string)
+	if access == "" || refresh == "" {
+		t.Fatalf("response has empty tokens: %v", body)
+	}
+
+	return access, refresh
+}
+
+// accessToken func for signing an access token with the given claims.
+func accessToken(t *testing.T, key string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+func TestUserSignUp(t *testing.T) {
+	s := newTestServer(t)
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", `{
+		"firstname": "Ada",
+		"lastname": "Lovelace",
+		"email": "Ada@Example.com",
+		"password": "correct horse"
+	}`, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", status, body)
+	}
+	tokensOf(t, body)
+
+	user, _ := body["user"].(map[string]interface{})
+	if user["email"] != "ada@example.com" {
+		t.Errorf("email %v, want it lowercased", user["email"])
+	}
+	if _, ok := user["password_hash"]; ok {
+		t.Error("password hash is in the response")
+	}
+	if user["user_role"] != repository.UserRoleName {
+		t.Errorf("role %v, want %s", user["user_role"], repository.UserRoleName)
+	}
+
+	// The user and the session are stored.
+	stored, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatalf("user was not stored: %v", err)
+	}
+	if _, err := s.sessions.Get(context.Background(), stored.ID); err != nil {
+		t.Errorf("session was not stored: %v", err)
+	}
+}
+
+func TestUserSignUpInvited(t *testing.T) {
+	s := newTestServer(t)
+	orgID, token := s.invite(t, "ada@example.com", repository.AdminRoleName)
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", `{
+		"firstname": "Ada",
+		"lastname": "Lovelace",
+		"email": "ada@example.com",
+		"password": "correct horse",
+		"invite_token": "`+token+`"
+	}`, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", status, body)
+	}
+	access, _ := tokensOf(t, body)
+
+	// The invited role applies to the organization only, not to the whole API.
+	user, _ := body["user"].(map[string]interface{})
+	if user["user_role"] != repository.UserRoleName {
+		t.Errorf("role %v, want %s", user["user_role"], repository.UserRoleName)
+	}
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users", "", access); status != fiber.StatusForbidden {
+		t.Errorf("admin users: status %d, want 403, body %v", status, body)
+	}
+
+	userID, _ := uuid.Parse(user["id"].(string))
+	membership, err := s.organizations.GetMembership(context.Background(), orgID, userID)
+	if err != nil {
+		t.Fatalf("membership was not stored: %v", err)
+	}
+	if membership.Role != repository.AdminRoleName {
+		t.Errorf("membership role %s, want %s", membership.Role, repository.AdminRoleName)
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	owner, _ := s.signUp(t, "owner@example.com", "correct horse")
+
+	_, body := s.do(t, http.MethodPost, "/api/v1/orgs", `{"name": "Analytical Engines"}`, owner)
+	org, _ := body["organization"].(map[string]interface{})
+	invitations := "/api/v1/orgs/" + org["id"].(string) + "/invitations"
+
+	// Invite a member, only one invitation of the email can be pending.
+	status, body := s.do(t, http.MethodPost, invitations, `{"email":"Ada@Example.com","role":"`+repository.UserRoleName+`"}`, owner)
+	if status != fiber.StatusOK {
+		t.Fatalf("create: status %d, want 200, body %v", status, body)
+	}
+	created, _ := body["invitation"].(map[string]interface{})
+	invitationID, _ := uuid.Parse(created["id"].(string))
+	if created["email"] != "ada@example.com" {
+		t.Errorf("email %v, want it lowercased", created["email"])
+	}
+	status, body = s.do(t, http.MethodPost, invitations, `{"email":"ada@example.com","role":"`+repository.UserRoleName+`"}`, owner)
+	if status != fiber.StatusConflict || body["code"] != apperror.CodeInvitationExists {
+		t.Errorf("duplicate: status %d, want 409 %s, body %v", status, apperror.CodeInvitationExists, body)
+	}
+	if _, body := s.do(t, http.MethodGet, invitations, "", owner); body["count"] != float64(1) {
+		t.Errorf("list: %v, want 1 pending invitation", body)
+	}
+
+	// Resending signs a new token, tokens issued before it are refused.
+	status, body = s.do(t, http.MethodPost, invitations+"/"+invitationID.String()+"/resend", "", owner)
+	if status != fiber.StatusOK {
+		t.Fatalf("resend: status %d, want 200, body %v", status, body)
+	}
+	invitation, err := s.invitations.GetByID(ctx, invitationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := utils.GenerateInviteToken(invitationID.String(), invitation.ExpiresAt.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := s.inviteToken(t, invitationID)
+
+	ada, _ := s.signUp(t, "ada@example.com", "correct horse")
+	status, body = s.do(t, http.MethodPost, "/api/v1/invitations/accept", `{"token":"`+replaced+`"}`, ada)
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeInvitationInvalid {
+		t.Errorf("replaced token: status %d, want 400 %s, body %v", status, apperror.CodeInvitationInvalid, body)
+	}
+
+	// The latest token is accepted once.
+	status, body = s.do(t, http.MethodPost, "/api/v1/invitations/accept", `{"token":"`+token+`"}`, ada)
+	if status != fiber.StatusOK {
+		t.Fatalf("accept: status %d, want 200, body %v", status, body)
+	}
+	status, body = s.do(t, http.MethodPost, "/api/v1/invitations/accept", `{"token":"`+token+`"}`, ada)
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeInvitationInvalid {
+		t.Errorf("reused token: status %d, want 400 %s, body %v", status, apperror.CodeInvitationInvalid, body)
+	}
+
+	// Revoked invitations can't be used to sign up, nor revoked again.
+	_, body = s.do(t, http.MethodPost, invitations, `{"email":"grace@example.com","role":"`+repository.UserRoleName+`"}`, owner)
+	created, _ = body["invitation"].(map[string]interface{})
+	revokedID, _ := uuid.Parse(created["id"].(string))
+	revoked := s.inviteToken(t, revokedID)
+	if status, body := s.do(t, http.MethodDelete, invitations+"/"+revokedID.String(), "", owner); status != fiber.StatusNoContent {
+		t.Fatalf("revoke: status %d, want 204, body %v", status, body)
+	}
+	if status, body := s.do(t, http.MethodDelete, invitations+"/"+revokedID.String(), "", owner); status != fiber.StatusNotFound {
+		t.Errorf("revoke again: status %d, want 404, body %v", status, body)
+	}
+	status, body = s.do(t, http.MethodPost, "/api/v1/auth/signup", `{
+		"firstname": "Grace",
+		"lastname": "Hopper",
+		"email": "grace@example.com",
+		"password": "correct horse",
+		"invite_token": "`+revoked+`"
+	}`, "")
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeInvitationInvalid {
+		t.Errorf("revoked token: status %d, want 400 %s, body %v", status, apperror.CodeInvitationInvalid, body)
+	}
+
+	// Expired invitations can't be accepted.
+	_, body = s.do(t, http.MethodPost, invitations, `{"email":"alan@example.com","role":"`+repository.UserRoleName+`"}`, owner)
+	created, _ = body["invitation"].(map[string]interface{})
+	expiredID, _ := uuid.Parse(created["id"].(string))
+	expired, err := s.invitations.GetByID(ctx, expiredID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	expiredToken, err := utils.GenerateInviteToken(expiredID.String(), expired.ExpiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired.TokenHash = utils.HashToken(expiredToken)
+	if err := s.invitations.Save(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	alan, _ := s.signUp(t, "alan@example.com", "correct horse")
+	status, body = s.do(t, http.MethodPost, "/api/v1/invitations/accept", `{"token":"`+expiredToken+`"}`, alan)
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeInvitationInvalid {
+		t.Errorf("expired token: status %d, want 400 %s, body %v", status, apperror.CodeInvitationInvalid, body)
+	}
+}
+
+func TestUserSignUpEmailTaken(t *testing.T) {
+	s := newTestServer(t)
+	s.signUp(t, "ada@example.com", "correct horse")
+
+	// Emails are compared case-insensitively.
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", `{
+		"firstname": "Ada",
+		"lastname": "Lovelace",
+		"email": "ADA@example.com",
+		"password": "another one"
+	}`, "")
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("status %d, want 400, body %v", status, body)
+	}
+	if body["code"] != "email_taken" || body["detail"] != "Email address is already registered" {
+		t.Errorf("code %v, detail %v", body["code"], body["detail"])
+	}
+}
+
+func TestUserSignUpValidation(t *testing.T) {
+	long := strings.Repeat("a", 256)
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+		want  string
+	}{
+		{
+			name:  "missing email",
+			body:  `{"firstname":"Ada","lastname":"Lovelace","password":"secret"}`,
+			field: "email",
+			want:  `property "email" is missing`,
+		},
+		{
+			name:  "invalid email",
+			body:  `{"firstname":"Ada","lastname":"Lovelace","email":"ada","password":"secret"}`,
+			field: "email",
+			want:  "'email' tag",
+		},
+		{
+			name:  "missing password",
+			body:  `{"firstname":"Ada","lastname":"Lovelace","email":"ada@example.com"}`,
+			field: "password",
+			want:  `property "password" is missing`,
+		},
+		{
+			name:  "missing first name",
+			body:  `{"lastname":"Lovelace","email":"ada@example.com","password":"secret"}`,
+			field: "firstname",
+			want:  `property "firstname" is missing`,
+		},
+		{
+			name:  "too long last name",
+			body:  `{"firstname":"Ada","lastname":"` + long + `","email":"ada@example.com","password":"secret"}`,
+			field: "lastname",
+			want:  "'lte' tag",
+		},
+		{
+			name:  "too long password",
+			body:  `{"firstname":"Ada","lastname":"Lovelace","email":"ada@example.com","password":"` + long + `"}`,
+			field: "password",
+			want:  "'lte' tag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", tt.body, "")
+			if status != fiber.StatusBadRequest {
+				t.Fatalf("status %d, want 400, body %v", status, body)
+			}
+
+			// Missing fields are found by the OpenAPI validation, other rules by utils.ValidatorErrors,
+			// both keyed by JSON field.
+			fields, ok := body["errors"].(map[string]interface{})
+			if !ok || body["code"] != "validation_failed" {
+				t.Fatalf("errors is not a field map: %v", body)
+			}
+			message, _ := fields[tt.field].(string)
+			if !strings.Contains(message, tt.want) {
+				t.Errorf("field %s: message %q, want %q", tt.field, message, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileAvatar(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+
+	// Only web links can be shown as avatars.
+	for _, url := range []string{"javascript:alert(1)", "data:image/png;base64,AAAA", "ftp://example.com/ada.png", "example.com/ada.png"} {
+		status, body := s.do(t, http.MethodPatch, "/api/v1/me", `{"avatar_url":"`+url+`"}`, access)
+		if status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400, body %v", url, status, body)
+		}
+	}
+
+	status, body := s.do(t, http.MethodPatch, "/api/v1/me", `{"avatar_url":"https://example.com/ada.png"}`, access)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", status, body)
+	}
+	if user, _ := body["user"].(map[string]interface{}); user["avatar_url"] != "https://example.com/ada.png" {
+		t.Errorf("avatar %v", user["avatar_url"])
+	}
+}
+
+func TestUserSignUpMalformedJSON(t *testing.T) {
+	s := newTestServer(t)
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signup", `{"email":`, "")
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("status %d, want 400, body %v", status, body)
+	}
+	if body["code"] != "invalid_body" {
+		t.Errorf("code %v, want invalid_body", body["code"])
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		token  string
+		fields map[string]string
+	}{
+		{
+			name:   "fields set by the server",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/signup",
+			body:   `{"firstname":"Eve","lastname":"Hacker","email":"eve@example.com","password":"secret","user_role":"admin","user_status":1,"id":"00000000-0000-0000-0000-000000000001"}`,
+			fields: map[string]string{"user_role": "unknown field", "user_status": "unknown field", "id": "unknown field"},
+		},
+		{
+			name:   "wrong type",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/signin",
+			body:   `{"email":"ada@example.com","password":42}`,
+			fields: map[string]string{"password": "value must be a string"},
+		},
+		{
+			name:   "missing body",
+			method: http.MethodPost,
+			path:   "/api/v1/auth/signin",
+			fields: map[string]string{"body": "request body is required"},
+		},
+		{
+			name:   "invalid query parameter",
+			method: http.MethodGet,
+			path:   "/api/v1/admin/users?limit=ten",
+			token:  access,
+			fields: map[string]string{"limit": `value ten: an invalid integer: invalid syntax`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.do(t, tt.method, tt.path, tt.body, tt.token)
+			if status != fiber.StatusBadRequest || body["code"] != "validation_failed" {
+				t.Fatalf("status %d, want 400 validation_failed, body %v", status, body)
+			}
+			fields, _ := body["errors"].(map[string]interface{})
+			if len(fields) != len(tt.fields) {
+				t.Errorf("errors %v, want %v", fields, tt.fields)
+			}
+			for field, want := range tt.fields {
+				if fields[field] != want {
+					t.Errorf("field %s: message %v, want %q", field, fields[field], want)
+				}
+			}
+		})
+	}
+
+	// Rejected requests never reach handlers.
+	if _, err := s.users.GetByEmail(context.Background(), "eve@example.com"); err == nil {
+		t.Error("user was created from a rejected request")
+	}
+
+	// Bodies must have a content type of the operation.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", strings.NewReader("email=ada@example.com"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	problem := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnsupportedMediaType || problem["code"] != "unsupported_media_type" {
+		t.Errorf("status %d, want 415 unsupported_media_type, body %v", resp.StatusCode, problem)
+	}
+}
+
+func TestScimUsers(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	owner, _ := s.signUp(t, "owner@example.com", "correct horse")
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/orgs", `{"name": "Analytical Engines"}`, owner)
+	if status != fiber.StatusOK {
+		t.Fatalf("create organization: status %d, body %v", status, body)
+	}
+	org, _ := body["organization"].(map[string]interface{})
+	orgID, _ := uuid.Parse(org["id"].(string))
+
+	status, body = s.do(t, http.MethodPost, "/api/v1/orgs/"+orgID.String()+"/scim-token", "", owner)
+	if status != fiber.StatusOK {
+		t.Fatalf("create SCIM token: status %d, body %v", status, body)
+	}
+	scimToken, _ := body["token"].(string)
+
+	// An existing account is not linked to the organization.
+	s.signUp(t, "ada@example.com", "correct horse")
+	status, body = s.do(t, http.MethodPost, "/scim/v2/Users", `{"userName": "Ada@example.com", "name": {"givenName": "Eve"}}`, scimToken)
+	if status != fiber.StatusConflict || body["scimType"] != "uniqueness" {
+		t.Fatalf("link existing account: status %d, body %v", status, body)
+	}
+
+	// A member who joined by invitation only has the membership managed.
+	ada, _ := s.users.GetByEmail(ctx, "ada@example.com")
+	if err := s.organizations.CreateMembership(ctx, &models.Membership{
+		ID: uuid.New(), CreatedAt: time.Now(), OrganizationID: orgID, UserID: ada.ID, Role: repository.UserRoleName,
+	}); err != nil {
+		t.Fatalf("create membership: %v", err)
+	}
+	path := "/scim/v2/Users/" + ada.ID.String()
+	for name, patch := range map[string]string{
+		"deactivate": `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "active", "value": false}]}`,
+		"email":      `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "userName", "value": "eve@example.com"}]}`,
+	} {
+		if status, body := s.do(t, http.MethodPatch, path, patch, scimToken); status != fiber.StatusForbidden {
+			t.Errorf("%s: status %d, want 403, body %v", name, status, body)
+		}
+	}
+	status, body = s.do(t, http.MethodPatch, path, `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "externalId", "value": "ada-1"}]}`, scimToken)
+	if status != fiber.StatusOK || body["externalId"] != "ada-1" {
+		t.Errorf("external ID: status %d, body %v", status, body)
+	}
+	if status, body := s.do(t, http.MethodDelete, path, "", scimToken); status != fiber.StatusNoContent {
+		t.Fatalf("delete: status %d, body %v", status, body)
+	}
+	if stored, _ := s.users.GetByID(ctx, ada.ID); stored.Email != "ada@example.com" || stored.UserStatus != 1 {
+		t.Errorf("account was changed: email %s, status %d", stored.Email, stored.UserStatus)
+	}
+	if _, err := s.sessions.Get(ctx, ada.ID); err != nil {
+		t.Errorf("session was revoked: %v", err)
+	}
+
+	// Accounts provisioned by the organization are managed by it.
+	status, body = s.do(t, http.MethodPost, "/scim/v2/Users", `{"userName": "grace@example.com", "name": {"givenName": "Grace"}}`, scimToken)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create: status %d, body %v", status, body)
+	}
+	path = "/scim/v2/Users/" + body["id"].(string)
+	for name, patch := range map[string]string{
+		"userName": `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "userName", "value": "not an email"}]}`,
+		"emails":   `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "emails", "value": [{"value": "grace", "primary": true}]}]}`,
+	} {
+		if status, body := s.do(t, http.MethodPatch, path, patch, scimToken); status != fiber.StatusBadRequest || body["scimType"] != "invalidValue" {
+			t.Errorf("invalid %s: status %d, want 400, body %v", name, status, body)
+		}
+	}
+	status, body = s.do(t, http.MethodPatch, path, `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "active", "value": false}]}`, scimToken)
+	if status != fiber.StatusOK || body["active"] != false {
+		t.Errorf("deactivate: status %d, body %v", status, body)
+	}
+}
+
+func TestAdminCredentials(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	user, admin := s.admin(t, "admin@example.com")
+
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users", "", admin); status != fiber.StatusOK {
+		t.Fatalf("admin: status %d, want 200, body %v", status, body)
+	}
+
+	// Credentials of a token with a forged role are not granted.
+	forged := accessToken(t, testSecretKey, jwt.MapClaims{
+		"id":          uuid.NewString(),
+		"exp":         time.Now().Add(time.Minute).Unix(),
+		"user:manage": true,
+	})
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users", "", forged); status != fiber.StatusForbidden {
+		t.Errorf("unknown user: status %d, want 403, body %v", status, body)
+	}
+
+	// Query parameters out of range are rejected.
+	for _, query := range []string{"limit=0", "status=2", "status=blocked"} {
+		if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users?"+query, "", admin); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400, body %v", query, status, body)
+		}
+	}
+
+	// A demoted or blocked admin loses access before the token expires.
+	user.UserRole = repository.UserRoleName
+	if err := s.users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users", "", admin); status != fiber.StatusForbidden {
+		t.Errorf("demoted: status %d, want 403, body %v", status, body)
+	}
+	user.UserRole, user.UserStatus = repository.AdminRoleName, 0
+	if err := s.users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/users", "", admin); status != fiber.StatusForbidden || body["code"] != "account_blocked" {
+		t.Errorf("blocked: status %d, want 403, body %v", status, body)
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	s := newTestServer(t)
+	_, admin := s.admin(t, "admin@example.com")
+	s.signUp(t, "ada@example.com", "correct horse")
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Erasing again does nothing.
+	for i := 0; i < 2; i++ {
+		status, body := s.do(t, http.MethodPost, "/api/v1/admin/users/"+user.ID.String()+"/erase", "", admin)
+		if status != fiber.StatusNoContent {
+			t.Fatalf("erase %d: status %d, want 204, body %v", i+1, status, body)
+		}
+	}
+
+	erased, err := s.users.GetByIDWithDeleted(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if erased.Email == "ada@example.com" || erased.FirstName != "" || !erased.DeletedAt.Valid {
+		t.Errorf("user was not anonymized: %+v", erased)
+	}
+	if _, err := s.sessions.Get(context.Background(), user.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("session was not deleted: %v", err)
+	}
+}
+
+func TestConfirmEmail(t *testing.T) {
+	s := newTestServer(t)
+	access, refresh := s.signUp(t, "ada@example.com", "correct horse")
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The email is changed only after confirmation.
+	status, body := s.do(t, http.MethodPost, "/api/v1/me/email", `{"email":"ada@lovelace.example.com","password":"correct horse"}`, access)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("change: status %d, want 202, body %v", status, body)
+	}
+	if stored, _ := s.users.GetByID(context.Background(), user.ID); stored.Email != "ada@example.com" {
+		t.Errorf("email %s was changed before confirmation", stored.Email)
+	}
+
+	token := s.emailChange(t, user.ID, "ada@lovelace.example.com", time.Hour)
+	status, body = s.do(t, http.MethodPost, "/api/v1/auth/email/confirm", `{"token":"`+user.ID.String()+`.wrong"}`, "")
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeConfirmationInvalid {
+		t.Errorf("wrong token: status %d, want 400 %s, body %v", status, apperror.CodeConfirmationInvalid, body)
+	}
+
+	status, body = s.do(t, http.MethodPost, "/api/v1/auth/email/confirm", `{"token":"`+token+`"}`, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("confirm: status %d, want 200, body %v", status, body)
+	}
+	tokensOf(t, body)
+	if stored, _ := s.users.GetByID(context.Background(), user.ID); stored.Email != "ada@lovelace.example.com" {
+		t.Errorf("email %s, want ada@lovelace.example.com", stored.Email)
+	}
+
+	// Earlier sessions ended, and the token can't be used again.
+	status, body = s.do(t, http.MethodPost, "/api/v1/token/renew", `{"refresh_token":"`+refresh+`"}`, access)
+	if status != fiber.StatusUnauthorized || body["code"] != "session_ended" {
+		t.Errorf("earlier session: status %d, want 401, body %v", status, body)
+	}
+	status, body = s.do(t, http.MethodPost, "/api/v1/auth/email/confirm", `{"token":"`+token+`"}`, "")
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeConfirmationInvalid {
+		t.Errorf("reused token: status %d, want 400 %s, body %v", status, apperror.CodeConfirmationInvalid, body)
+	}
+
+	// Expired tokens are refused.
+	expired := s.emailChange(t, user.ID, "ada@example.com", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	status, body = s.do(t, http.MethodPost, "/api/v1/auth/email/confirm", `{"token":"`+expired+`"}`, "")
+	if status != fiber.StatusBadRequest || body["code"] != apperror.CodeConfirmationInvalid {
+		t.Errorf("expired token: status %d, want 400 %s, body %v", status, apperror.CodeConfirmationInvalid, body)
+	}
+}
+
+func TestDataExport(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+	other, _ := s.signUp(t, "grace@example.com", "correct horse")
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/me/export", "", access)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("request: status %d, want 202, body %v", status, body)
+	}
+	export, _ := body["export"].(map[string]interface{})
+	exportPath := "/api/v1/me/exports/" + export["id"].(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := controllers.WaitBackground(ctx); err != nil {
+		t.Fatalf("export was not built: %v", err)
+	}
+
+	status, body = s.do(t, http.MethodGet, exportPath, "", access)
+	if export, _ := body["export"].(map[string]interface{}); status != fiber.StatusOK || export["status"] != models.DataExportStatusReady {
+		t.Fatalf("status: %d, body %v, want a ready export", status, body)
+	}
+
+	// The archive is a ZIP file.
+	req := httptest.NewRequest(http.MethodGet, exportPath+"/download", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK || !bytes.HasPrefix(raw, []byte("PK")) {
+		t.Errorf("download: status %d, content type %q, want a ZIP archive", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+
+	// Other users can't see it.
+	for _, path := range []string{exportPath, exportPath + "/download"} {
+		if status, body := s.do(t, http.MethodGet, path, "", other); status != fiber.StatusNotFound || body["code"] != apperror.CodeExportNotFound {
+			t.Errorf("%s by other user: status %d, want 404, body %v", path, status, body)
+		}
+	}
+
+	// An export being prepared is returned again, and can't be downloaded yet.
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := &models.DataExport{ID: uuid.New(), CreatedAt: time.Now(), UserID: user.ID, Status: models.DataExportStatusPending}
+	if err := s.privacy.CreateExport(context.Background(), pending); err != nil {
+		t.Fatal(err)
+	}
+	status, body = s.do(t, http.MethodPost, "/api/v1/me/export", "", access)
+	if export, _ := body["export"].(map[string]interface{}); status != fiber.StatusAccepted || export["id"] != pending.ID.String() {
+		t.Errorf("reuse: status %d, body %v, want pending export %s", status, body, pending.ID)
+	}
+	status, body = s.do(t, http.MethodGet, "/api/v1/me/exports/"+pending.ID.String()+"/download", "", access)
+	if status != fiber.StatusConflict || body["code"] != apperror.CodeExportNotReady {
+		t.Errorf("download pending: status %d, want 409 %s, body %v", status, apperror.CodeExportNotReady, body)
+	}
+}
+
+func TestDataExportLost(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An export left pending, like by a restart during the build.
+	lost := &models.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().Add(-time.Hour),
+		UserID:    user.ID,
+		Status:    models.DataExportStatusPending,
+	}
+	if err := s.privacy.CreateExport(context.Background(), lost); err != nil {
+		t.Fatal(err)
+	}
+
+	// It doesn't block a new export, and is failed.
+	status, body := s.do(t, http.MethodPost, "/api/v1/me/export", "", access)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("status %d, want 202, body %v", status, body)
+	}
+	if export, _ := body["export"].(map[string]interface{}); export["id"] == lost.ID.String() {
+		t.Errorf("lost export %s was returned", lost.ID)
+	}
+	if stored, _ := s.privacy.GetExport(context.Background(), lost.ID); stored.Status != models.DataExportStatusFailed {
+		t.Errorf("lost export status %s, want failed", stored.Status)
+	}
+}
+
+func TestUserSignIn(t *testing.T) {
+	s := newTestServer(t)
+	s.signUp(t, "ada@example.com", "correct horse")
+
+	blocked := &models.User{
+		ID:           uuid.New(),
+		CreatedAt:    time.Now(),
+		Email:        "blocked@example.com",
+		PasswordHash: utils.GeneratePassword("correct horse"),
+		UserStatus:   0,
+		UserRole:     repository.UserRoleName,
+	}
+	if err := s.users.Create(context.Background(), blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{
+			name:   "valid credentials",
+			body:   `{"email":"ada@example.com","password":"correct horse"}`,
+			status: fiber.StatusOK,
+		},
+		{
+			name:   "email in other case",
+			body:   `{"email":"ADA@EXAMPLE.COM","password":"correct horse"}`,
+			status: fiber.StatusOK,
+		},
+		{
+			name:    "wrong password",
+			body:    `{"email":"ada@example.com","password":"wrong"}`,
+			status:  fiber.StatusBadRequest,
+			message: "The email address or password is wrong",
+		},
+		{
+			name:    "unknown email",
+			body:    `{"email":"nobody@example.com","password":"correct horse"}`,
+			status:  fiber.StatusNotFound,
+			message: "The email address does not exist",
+		},
+		{
+			name:    "blocked user",
+			body:    `{"email":"blocked@example.com","password":"correct horse"}`,
+			status:  fiber.StatusForbidden,
+			message: "The user account is blocked",
+		},
+		{
+			name:   "malformed JSON",
+			body:   `{"email"`,
+			status: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.do(t, http.MethodPost, "/api/v1/auth/signin", tt.body, "")
+			if status != tt.status {
+				t.Fatalf("status %d, want %d, body %v", status, tt.status, body)
+			}
+			if tt.status == fiber.StatusOK {
+				tokensOf(t, body)
+			}
+			if tt.message != "" && body["detail"] != tt.message {
+				t.Errorf("detail %v, want %q", body["detail"], tt.message)
+			}
+		})
+	}
+}
+
+func TestRenewTokens(t *testing.T) {
+	s := newTestServer(t)
+	access, refresh := s.signUp(t, "ada@example.com", "correct horse")
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/token/renew", `{"refresh_token":"`+refresh+`"}`, access)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", status, body)
+	}
+	_, renewed := tokensOf(t, body)
+
+	// The new refresh token replaces the stored session.
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.sessions.Get(context.Background(), user.ID)
+	if err != nil || stored != utils.HashToken(renewed) {
+		t.Errorf("stored session %q, want hash of %q (err %v)", stored, renewed, err)
+	}
+
+	// The replaced refresh token can't be used again.
+	status, body = s.do(t, http.MethodPost, "/api/v1/token/renew", `{"refresh_token":"`+refresh+`"}`, access)
+	if status != fiber.StatusUnauthorized || body["code"] != "session_ended" {
+		t.Errorf("replayed refresh token: status %d, want 401, body %v", status, body)
+	}
+}
+
+func TestRenewTokensErrors(t *testing.T) {
+	s := newTestServer(t)
+	access, refresh := s.signUp(t, "ada@example.com", "correct horse")
+
+	expiredAccess := accessToken(t, testSecretKey, jwt.MapClaims{
+		"id":  uuid.NewString(),
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	unknownUser := accessToken(t, testSecretKey, jwt.MapClaims{
+		"id":  uuid.NewString(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	expiredRefresh := "0123abcd." + strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	forgedRefresh := utils.HashToken("forged") + "." + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{
+			name:   "expired access token",
+			token:  expiredAccess,
+			body:   `{"refresh_token":"` + refresh + `"}`,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "malformed refresh token",
+			token:  access,
+			body:   `{"refresh_token":"garbage"}`,
+			status: fiber.StatusBadRequest,
+		},
+		{
+			name:   "expired refresh token",
+			token:  access,
+			body:   `{"refresh_token":"` + expiredRefresh + `"}`,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "refresh token of no session",
+			token:  access,
+			body:   `{"refresh_token":"` + forgedRefresh + `"}`,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "unknown user has no session",
+			token:  unknownUser,
+			body:   `{"refresh_token":"` + refresh + `"}`,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "malformed JSON",
+			token:  access,
+			body:   `{"refresh_token"`,
+			status: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.do(t, http.MethodPost, "/api/v1/token/renew", tt.body, tt.token)
+			if status != tt.status {
+				t.Errorf("status %d, want %d, body %v", status, tt.status, body)
+			}
+		})
+	}
+
+	// A user blocked meanwhile can't renew the session.
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.UserStatus = 0
+	if err := s.users.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	status, body := s.do(t, http.MethodPost, "/api/v1/token/renew", `{"refresh_token":"`+refresh+`"}`, access)
+	if status != fiber.StatusForbidden || body["code"] != "account_blocked" {
+		t.Errorf("blocked user: status %d, want 403, body %v", status, body)
+	}
+}
+
+func TestUserSignOut(t *testing.T) {
+	s := newTestServer(t)
+	access, refresh := s.signUp(t, "ada@example.com", "correct horse")
+
+	status, body := s.do(t, http.MethodPost, "/api/v1/auth/signout", "", access)
+	if status != fiber.StatusNoContent {
+		t.Fatalf("status %d, want 204, body %v", status, body)
+	}
+
+	// The session is gone.
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.sessions.Get(context.Background(), user.ID); err != repository.ErrNotFound {
+		t.Errorf("session after sign out: err %v, want ErrNotFound", err)
+	}
+
+	// The refresh token stops working, though the access token is valid until it expires.
+	status, body = s.do(t, http.MethodPost, "/api/v1/token/renew", `{"refresh_token":"`+refresh+`"}`, access)
+	if status != fiber.StatusUnauthorized || body["code"] != "session_ended" {
+		t.Errorf("renew after sign out: status %d, want 401, body %v", status, body)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep a valid request ID from the caller.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signout", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	req.Header.Set("X-Request-ID", "req-42")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "req-42" {
+		t.Errorf("X-Request-ID %q, want req-42", got)
+	}
+
+	var record map[string]interface{}
+	for _, r := range s.logs.records(t, "request") {
+		if r["request_id"] == "req-42" {
+			record = r
+		}
+	}
+	if record == nil {
+		t.Fatalf("no access log with the request ID, logs:\n%s", s.logs)
+	}
+	want := map[string]interface{}{
+		"component": "http",
+		"method":    "POST",
+		"route":     "/api/v1/auth/signout",
+		"status":    float64(fiber.StatusNoContent),
+		"user_id":   user.ID.String(),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("access log %s = %v, want %v", key, record[key], value)
+		}
+	}
+
+	// Replace a request ID unsafe to log.
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", "bad id\"}")
+	resp, err = s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, err := uuid.Parse(resp.Header.Get("X-Request-ID")); err != nil {
+		t.Errorf("X-Request-ID %q, want a generated UUID", resp.Header.Get("X-Request-ID"))
+	}
+
+	// Personal data and secrets never reach the logs.
+	for _, secret := range []string{"ada@example.com", "correct horse", access} {
+		if strings.Contains(s.logs.String(), secret) {
+			t.Errorf("logs contain %q", secret)
+		}
+	}
+}
+
+func TestLogRedaction(t *testing.T) {
+	s := newTestServer(t)
+
+	logging.For("app").Error("sign in failed for ada@example.com with Bearer abc.def.ghi",
+		"password", "correct horse",
+		"Authorization", "Basic YWRhOmNvcnJlY3Q=",
+		"refresh_token", "0123456789abcdef",
+		"link", "https://figbase.co/email/confirm?token=s3cr3t&lang=en",
+		"error", errors.New(`duplicate key value (lower(email))=(ada@example.com)`),
+	)
+
+	records := s.logs.records(t, "sign in failed for ***@example.com with Bearer [REDACTED]")
+	if len(records) != 1 {
+		t.Fatalf("no redacted record, logs:\n%s", s.logs)
+	}
+	want := map[string]interface{}{
+		"password":      "[REDACTED]",
+		"Authorization": "[REDACTED]",
+		"refresh_token": "[REDACTED]",
+		"link":          "https://figbase.co/email/confirm?token=[REDACTED]&lang=en",
+		"error":         "duplicate key value (lower(email))=(***@example.com)",
+	}
+	for key, value := range want {
+		if records[0][key] != value {
+			t.Errorf("%s = %v, want %v", key, records[0][key], value)
+		}
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	s := newTestServer(t)
+	access, _ := s.signUp(t, "ada@example.com", "correct horse")
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.do(t, http.MethodPost, "/api/v1/auth/signin", `{"email":"ada@example.com","password":"wrong"}`, "")
+	s.do(t, http.MethodPost, "/api/v1/auth/signin", `{"email":"nobody@example.com","password":"wrong"}`, "")
+	s.do(t, http.MethodPost, "/api/v1/auth/signout", "", access)
+
+	// Only tokens with the audit:read credential can read the log.
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/audit", "", access); status != fiber.StatusForbidden {
+		t.Fatalf("user token: status %d, want 403, body %v", status, body)
+	}
+	_, admin := s.admin(t, "admin@example.com")
+
+	// Newest events first.
+	status, body := s.do(t, http.MethodGet, "/api/v1/admin/audit", "", admin)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", status, body)
+	}
+	events, _ := body["events"].([]interface{})
+	want := []struct{ action, outcome, reason string }{
+		{"auth.signout", "success", ""},
+		{"auth.signin", "failure", "unknown_email"},
+		{"auth.signin", "failure", "wrong_password"},
+		{"auth.signup", "success", ""},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		event := events[i].(map[string]interface{})
+		metadata, _ := event["metadata"].(map[string]interface{})
+		if event["action"] != w.action || event["outcome"] != w.outcome || (w.reason != "" && metadata["reason"] != w.reason) {
+			t.Errorf("event %d: %v, want %+v", i, event, w)
+		}
+		if w.reason != "unknown_email" && event["actor_id"] != user.ID.String() {
+			t.Errorf("event %d: actor_id %v, want %s", i, event["actor_id"], user.ID)
+		}
+		if _, ok := metadata["email_hash"]; ok {
+			t.Errorf("event %d keeps a trace of the email: %v", i, metadata)
+		}
+	}
+
+	// Filters and cursor pagination.
+	_, body = s.do(t, http.MethodGet, "/api/v1/admin/audit?action=auth.signin&outcome=failure&limit=1", "", admin)
+	if body["count"] != float64(1) || body["next_cursor"] == nil {
+		t.Fatalf("first page: %v", body)
+	}
+	cursor := strconv.FormatFloat(body["next_cursor"].(float64), 'f', 0, 64)
+	_, body = s.do(t, http.MethodGet, "/api/v1/admin/audit?action=auth.signin&outcome=failure&limit=1&cursor="+cursor, "", admin)
+	if body["count"] != float64(1) || body["next_cursor"] != nil {
+		t.Fatalf("last page: %v", body)
+	}
+	if status, body := s.do(t, http.MethodGet, "/api/v1/admin/audit?actor_id=nope", "", admin); status != fiber.StatusBadRequest {
+		t.Errorf("invalid filter: status %d, want 400, body %v", status, body)
+	}
+
+	// Exports have one line per event, CSV with a header.
+	for format, lines := range map[string]int{"ndjson": 4, "csv": 5} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit/export?format="+format, nil)
+		req.Header.Set("Authorization", "Bearer "+admin)
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s export: status %d, body %s", format, resp.StatusCode, raw)
+		}
+		if got := strings.Count(string(raw), "\n"); got != lines {
+			t.Errorf("%s export: %d lines, want %d:\n%s", format, got, lines, raw)
+		}
+	}
+
+	// The hash chain is intact.
+	_, body = s.do(t, http.MethodGet, "/api/v1/admin/audit/verify", "", admin)
+	if body["valid"] != true || body["checked"] != float64(4) {
+		t.Errorf("verify: %v", body)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := newTestServer(t)
+	s.signUp(t, "ada@example.com", "correct horse")
+
+	signIn := func(email string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", strings.NewReader(`{"email":"`+email+`","password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Headers describe the stricter per-email policy (5/1m) of the route.
+	for i := 1; i <= 5; i++ {
+		resp := signIn("ada@example.com")
+		if resp.StatusCode == fiber.StatusTooManyRequests {
+			t.Fatalf("attempt %d was limited", i)
+		}
+		if got, want := resp.Header.Get("RateLimit-Remaining"), strconv.Itoa(5-i); got != want {
+			t.Errorf("attempt %d: RateLimit-Remaining %q, want %q", i, got, want)
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != "5" {
+			t.Errorf("attempt %d: RateLimit-Limit %q, want 5", i, got)
+		}
+	}
+
+	// The next attempt on the account is refused, also with other case.
+	resp := signIn("ADA@example.com")
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", resp.StatusCode)
+	}
+	if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err != nil || retry < 1 || retry > 12 {
+		t.Errorf("Retry-After %q, want 1-12 seconds", resp.Header.Get("Retry-After"))
+	}
+
+	// Other accounts from the same IP are still allowed.
+	if resp := signIn("grace@example.com"); resp.StatusCode == fiber.StatusTooManyRequests {
+		t.Errorf("other email was limited")
+	}
+}
+
+// failingLimiter struct to describe a rate limiter whose storage is down.
+type failingLimiter struct{}
+
+// Allow method to fail every check.
+func (failingLimiter) Allow(context.Context, string, int, time.Duration) (middleware.RateLimitResult, error) {
+	return middleware.RateLimitResult{}, errors.New("connection refused")
+}
+
+func TestRateLimitUnavailable(t *testing.T) {
+	for _, failOpen := range []bool{true, false} {
+		s := newTestServer(t)
+		cfg := config.Default().RateLimit
+		cfg.FailOpen = failOpen
+		middleware.ConfigureRateLimit(&cfg, failingLimiter{})
+
+		status, body := s.do(t, http.MethodPost, "/api/v1/auth/signin", `{"email":"ada@example.com","password":"wrong"}`, "")
+		if failOpen && status == fiber.StatusServiceUnavailable {
+			t.Errorf("fail open: status 503, want the request to pass")
+		}
+		if !failOpen && status != fiber.StatusServiceUnavailable {
+			t.Errorf("fail closed: status %d, want 503, body %v", status, body)
+		}
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	s := newTestServer(t)
+
+	signUp := func(key, email string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+
+		body := `{"firstname":"Ada","lastname":"Lovelace","email":"` + email + `","password":"correct horse"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		result := map[string]interface{}{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return resp, result
+	}
+
+	// A retry gets the first response, the user is created once.
+	first, created := signUp("key-1", "ada@example.com")
+	if first.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, want 200, body %v", first.StatusCode, created)
+	}
+	retry, replayed := signUp("key-1", "ada@example.com")
+	if retry.StatusCode != fiber.StatusOK || retry.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: status %d, replayed %q, body %v", retry.StatusCode, retry.Header.Get("Idempotent-Replayed"), replayed)
+	}
+	if access, _ := tokensOf(t, replayed); access != created["tokens"].(map[string]interface{})["access"] {
+		t.Error("retry got other tokens than the first response")
+	}
+
+	// A key is bound to its payload.
+	resp, body := signUp("key-1", "grace@example.com")
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || body["code"] != "idempotency_key_reused" {
+		t.Errorf("other payload: status %d, body %v", resp.StatusCode, body)
+	}
+	if _, err := s.users.GetByEmail(context.Background(), "grace@example.com"); err == nil {
+		t.Error("user was created with a reused key")
+	}
+
+	// Error responses are not kept, the request is handled again.
+	resp, body = signUp("key-2", "ada@example.com")
+	if resp.StatusCode != fiber.StatusBadRequest || body["code"] != "email_taken" {
+		t.Fatalf("taken email: status %d, body %v", resp.StatusCode, body)
+	}
+	resp, _ = signUp("key-2", "ada@example.com")
+	if resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Error("error response was replayed")
+	}
+
+	// A retry while the first request is in flight is refused.
+	body3 := `{"firstname":"Ada","lastname":"Lovelace","email":"alan@example.com","password":"correct horse"}`
+	inFlight := &middleware.IdempotencyRecord{
+		Lock:        "first request",
+		RequestHash: utils.HashToken(http.MethodPost + " /api/v1/auth/signup\n" + body3),
+	}
+	if _, err := s.idempotency.Lock(context.Background(), "idempotency:anonymous:"+utils.HashToken("key-3"), inFlight, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	resp, body = signUp("key-3", "alan@example.com")
+	if resp.StatusCode != fiber.StatusConflict || body["code"] != "idempotency_in_progress" || resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Errorf("in flight: status %d, body %v", resp.StatusCode, body)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	s := newTestServer(t)
+
+	for path, csp := range map[string]string{
+		"/api/v1/":   "default-src 'none'",
+		"/healthz":   "default-src 'none'",
+		"/api/docs/": "default-src 'self'",
+	} {
+		resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Content-Security-Policy"); !strings.HasPrefix(got, csp) {
+			t.Errorf("%s: Content-Security-Policy %q, want %q", path, got, csp)
+		}
+		for header, want := range map[string]string{
+			"X-Frame-Options":        "DENY",
+			"X-Content-Type-Options": "nosniff",
+			"Referrer-Policy":        "no-referrer",
+		} {
+			if got := resp.Header.Get(header); got != want {
+				t.Errorf("%s: %s %q, want %q", path, header, got, want)
+			}
+		}
+		if got := resp.Header.Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("%s: HSTS %q over plain HTTP", path, got)
+		}
+	}
+
+	// HSTS is sent on HTTPS, as told by the load balancer.
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Strict-Transport-Security"); got != "max-age=15552000; includeSubDomains" {
+		t.Errorf("HSTS %q", got)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	preflight := func(s *testServer, origin string) *http.Response {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/token/renew", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "authorization,x-csrf-token")
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// No origin is allowed by default.
+	resp := preflight(newTestServer(t), "https://app.figbase.co")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("default: Access-Control-Allow-Origin %q", got)
+	}
+
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.CORS.AllowOrigins = []string{"https://app.figbase.co"}
+		cfg.CORS.AllowCredentials = true
+	})
+	resp = preflight(s, "https://app.figbase.co")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.figbase.co" {
+		t.Errorf("allowed origin: Access-Control-Allow-Origin %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("allowed origin: Access-Control-Allow-Credentials %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); !strings.Contains(got, "X-CSRF-Token") {
+		t.Errorf("allowed origin: Access-Control-Allow-Headers %q", got)
+	}
+
+	resp = preflight(s, "https://evil.example.com")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("other origin: Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Session.Mode = "cookie"
+	})
+
+	// Safe requests get the CSRF cookie, readable by scripts.
+	resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var csrf *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "csrf_token" {
+			csrf = cookie
+		}
+	}
+	if csrf == nil || csrf.Value == "" || csrf.HttpOnly || !csrf.Secure || csrf.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("CSRF cookie %+v", csrf)
+	}
+
+	renew := func(header string, cookies ...*http.Cookie) map[string]interface{} {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/token/renew", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body := map[string]interface{}{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+	session := &http.Cookie{Name: "refresh_token", Value: "refresh"}
+
+	// Requests sending the session cookie must repeat the CSRF cookie in the header.
+	for name, body := range map[string]map[string]interface{}{
+		"no header":    renew("", session, csrf),
+		"wrong header": renew("forged", session, csrf),
+		"no cookie":    renew(csrf.Value, session),
+	} {
+		if body["status"] != float64(fiber.StatusForbidden) || body["code"] != "csrf_invalid" {
+			t.Errorf("%s: body %v, want 403 csrf_invalid", name, body)
+		}
+	}
+
+	// Matching tokens, or no session cookie at all, pass on to authentication.
+	for name, body := range map[string]map[string]interface{}{
+		"matching header":   renew(csrf.Value, session, csrf),
+		"no session cookie": renew(""),
+	} {
+		if body["code"] == "csrf_invalid" {
+			t.Errorf("%s: refused by CSRF check, body %v", name, body)
+		}
+	}
+}
+
+func TestCookieSession(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Session.Mode = "cookie"
+	})
+
+	send := func(path, body, access string, cookies map[string]*http.Cookie) (*http.Response, map[string]interface{}) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if access != "" {
+			req.Header.Set("Authorization", "Bearer "+access)
+		}
+		if csrf, ok := cookies["csrf_token"]; ok {
+			req.Header.Set("X-CSRF-Token", csrf.Value)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		result := map[string]interface{}{}
+		if raw, _ := io.ReadAll(resp.Body); len(raw) > 0 {
+			if err := json.Unmarshal(raw, &result); err != nil {
+				t.Fatalf("%s: body is not JSON: %s", path, raw)
+			}
+		}
+		return resp, result
+	}
+	cookiesOf := func(resp *http.Response) map[string]*http.Cookie {
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range resp.Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		return cookies
+	}
+
+	// Sign up sets the refresh token in an HttpOnly cookie scoped to the token routes, not in the body.
+	resp, body := send("/api/v1/auth/signup", `{"firstname":"Ada","lastname":"Lovelace","email":"ada@example.com","password":"correct horse"}`, "", nil)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("sign up: status %d, body %v", resp.StatusCode, body)
+	}
+	tokens, _ := body["tokens"].(map[string]interface{})
+	access, _ := tokens["access"].(string)
+	if _, ok := tokens["refresh"]; ok || access == "" {
+		t.Errorf("tokens %v, want the access token only", tokens)
+	}
+	cookies := cookiesOf(resp)
+	refresh := cookies["refresh_token"]
+	if refresh == nil || refresh.Value == "" || !refresh.HttpOnly || !refresh.Secure ||
+		refresh.SameSite != http.SameSiteStrictMode || refresh.Path != "/api/v1/token" || !refresh.Expires.After(time.Now()) {
+		t.Fatalf("refresh token cookie %+v", refresh)
+	}
+	if cookies["csrf_token"] == nil {
+		t.Fatal("sign up did not set the CSRF cookie")
+	}
+
+	// Renewal reads the refresh token from the cookie and rotates it.
+	resp, body = send("/api/v1/token/renew", "", access, cookies)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("renew: status %d, body %v", resp.StatusCode, body)
+	}
+	renewed := cookiesOf(resp)["refresh_token"]
+	if renewed == nil || renewed.Value == refresh.Value {
+		t.Fatalf("renewed refresh token cookie %+v", renewed)
+	}
+	user, err := s.users.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := s.sessions.Get(context.Background(), user.ID); err != nil || stored != utils.HashToken(renewed.Value) {
+		t.Errorf("stored session %q, want hash of %q (err %v)", stored, renewed.Value, err)
+	}
+
+	// Sign out clears the cookie.
+	resp, body = send("/api/v1/auth/signout", "", access, nil)
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("sign out: status %d, body %v", resp.StatusCode, body)
+	}
+	cleared := cookiesOf(resp)["refresh_token"]
+	if cleared == nil || cleared.Value != "" || cleared.Expires.After(time.Now()) || cleared.Path != "/api/v1/token" {
+		t.Errorf("cleared refresh token cookie %+v", cleared)
+	}
+
+	// Without the cookie, renewal has no refresh token.
+	resp, body = send("/api/v1/token/renew", "", access, nil)
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("renew without cookie: status %d, want 400, body %v", resp.StatusCode, body)
+	}
+}
+
+func TestJWTErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	wrongKey := accessToken(t, "another-key", jwt.MapClaims{
+		"id":  uuid.NewString(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	expired := accessToken(t, testSecretKey, jwt.MapClaims{
+		"id":  uuid.NewString(),
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	noneAlg, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"id":  uuid.NewString(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		message string
+	}{
+		{
+			name:    "missing token",
+			status:  fiber.StatusBadRequest,
+			message: "missing or malformed JWT",
+		},
+		{
+			name:   "garbage token",
+			token:  "not-a-jwt",
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "wrong signing key",
+			token:  wrongKey,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "unsigned token",
+			token:  noneAlg,
+			status: fiber.StatusUnauthorized,
+		},
+		{
+			name:    "expired token",
+			token:   expired,
+			status:  fiber.StatusUnauthorized,
+			message: "token has expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.do(t, http.MethodPost, "/api/v1/auth/signout", "", tt.token)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d, body %v", status, tt.status, body)
+			}
+			if tt.message != "" && body["detail"] != tt.message {
+				t.Errorf("detail %v, want %q", body["detail"], tt.message)
+			}
+		})
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	s := newTestServer(t)
+
+	status, body := s.do(t, http.MethodGet, "/api/v1/no-such-endpoint", "", "")
+	if status != fiber.StatusNotFound {
+		t.Fatalf("status %d, want 404, body %v", status, body)
+	}
+	if body["code"] != "route_not_found" || body["detail"] != "Sorry, endpoint is not found" {
+		t.Errorf("code %v, detail %v", body["code"], body["detail"])
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	s := newTestServer(t)
+
+	// Routes failing in different ways, behind the app middleware.
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	middleware.FiberMiddleware(app, config.Default())
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return apperror.Conflict(apperror.CodeEmailTaken, "Email address is already registered")
+	})
+	app.Get("/internal", func(c *fiber.Ctx) error {
+		return errors.New(`pq: relation "users" does not exist`)
+	})
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("nil map write in handler")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+		detail string
+	}{
+		{path: "/conflict", status: fiber.StatusConflict, code: "email_taken", detail: "Email address is already registered"},
+		{path: "/internal", status: fiber.StatusInternalServerError, code: "internal_error", detail: "internal server error"},
+		{path: "/panic", status: fiber.StatusInternalServerError, code: "internal_error", detail: "internal server error"},
+		{path: "/missing", status: fiber.StatusNotFound, code: "not_found", detail: "Cannot GET /missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); ct != "application/problem+json" {
+				t.Errorf("content type %q, want application/problem+json", ct)
+			}
+			var problem apperror.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			want := apperror.Problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(tt.status),
+				Status:    tt.status,
+				Detail:    tt.detail,
+				Instance:  tt.path,
+				Code:      tt.code,
+				RequestID: resp.Header.Get("X-Request-ID"),
+			}
+			if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status ||
+				problem.Detail != want.Detail || problem.Instance != want.Instance ||
+				problem.Code != want.Code || problem.RequestID != want.RequestID {
+				t.Errorf("problem %+v, want %+v", problem, want)
+			}
+		})
+	}
+
+	// Internal details are logged, not sent.
+	if records := s.logs.records(t, "request failed"); len(records) != 2 {
+		t.Errorf("%d failed requests logged, want 2, logs:\n%s", len(records), s.logs)
+	} else if !strings.Contains(records[0]["error"].(string), `relation "users" does not exist`) {
+		t.Errorf("error %v, want the internal cause", records[0]["error"])
+	}
+	if records := s.logs.records(t, "panic"); len(records) != 1 || records[0]["stack"] == "" {
+		t.Errorf("no panic with stack logged, logs:\n%s", s.logs)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s := newTestServer(t)
+
+	status, body := s.do(t, http.MethodGet, "/healthz", "", "")
+	if status != fiber.StatusOK || body["status"] != "ok" {
+		t.Fatalf("liveness: status %d, body %v", status, body)
+	}
+
+	status, body = s.do(t, http.MethodGet, "/readyz", "", "")
+	if status != fiber.StatusOK || body["status"] != "ok" {
+		t.Fatalf("readiness: status %d, body %v", status, body)
+	}
+	checks, _ := body["checks"].(map[string]interface{})
+	for _, name := range []string{"postgres", "redis"} {
+		check, _ := checks[name].(map[string]interface{})
+		if check["status"] != "ok" {
+			t.Errorf("check %s: %v", name, check)
+		}
+		if _, ok := check["latency_ms"].(float64); !ok {
+			t.Errorf("check %s has no latency: %v", name, check)
+		}
+	}
+}
+
+func TestReadinessFailingDependency(t *testing.T) {
+	s := newTestServer(t)
+	s.redisErr = errors.New("connection refused")
+
+	status, body := s.do(t, http.MethodGet, "/readyz", "", "")
+	if status != fiber.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503, body %v", status, body)
+	}
+	checks, _ := body["checks"].(map[string]interface{})
+	redis, _ := checks["redis"].(map[string]interface{})
+	if redis["status"] != "error" || redis["error"] != "connection refused" {
+		t.Errorf("redis check %v", redis)
+	}
+
+	// Liveness does not depend on dependencies.
+	if status, _ := s.do(t, http.MethodGet, "/healthz", "", ""); status != fiber.StatusOK {
+		t.Errorf("liveness status %d, want 200", status)
+	}
+}
+
+func TestReadinessDuringShutdown(t *testing.T) {
+	s := newTestServer(t)
+	s.health.SetReady(false)
+
+	status, body := s.do(t, http.MethodGet, "/readyz", "", "")
+	if status != fiber.StatusServiceUnavailable || body["shutting_down"] != true {
+		t.Fatalf("status %d, body %v", status, body)
+	}
+}
+
+// undocumentedRoutes are routes not described in the OpenAPI document, they are no API.
+var undocumentedRoutes = map[string]bool{
+	"GET /metrics":                         true,
+	"GET /api/docs":                        true,
+	"GET /api/docs/openapi.json":           true,
+	"GET /api/docs/swagger-initializer.js": true,
+}
+
+func TestOpenAPI(t *testing.T) {
+	s := newTestServer(t)
+
+	// The served document is generated from the current annotations.
+	doc, err := openapi.Generate("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := doc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, docs.OpenAPI) {
+		t.Fatal("docs/openapi.json is out of date, run go generate ./docs")
+	}
+
+	// Each route of the app is described, and each description has a route.
+	routes := map[string]bool{}
+	for _, route := range s.app.GetRoutes(true) {
+		key := route.Method + " " + fiberPathParam.ReplaceAllString(route.Path, "{$1}")
+		if route.Method != http.MethodHead && !undocumentedRoutes[key] {
+			routes[key] = true
+		}
+	}
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			if !routes[key] {
+				t.Errorf("%s is described, but not routed", key)
+			}
+			delete(routes, key)
+		}
+	}
+	for key := range routes {
+		t.Errorf("%s is routed, but not described, add swag annotations to its handler", key)
+	}
+
+	// The document and Swagger UI are served.
+	resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, "/api/docs/openapi.json", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	served := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&served); err != nil || served["openapi"] != "3.0.3" {
+		t.Errorf("openapi.json: status %d, openapi %v, error %v", resp.StatusCode, served["openapi"], err)
+	}
+
+	for path, want := range map[string]string{
+		"/api/docs/":                       `<div id="swagger-ui">`,
+		"/api/docs/swagger-initializer.js": `url: "/api/docs/openapi.json"`,
+		"/api/docs/swagger-ui-bundle.js":   "SwaggerUIBundle",
+	} {
+		resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("%s: status %d, want 200 with %q", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t)
+	s.signUp(t, "ada@example.com", "correct horse")
+	s.do(t, http.MethodPost, "/api/v1/auth/signin", `{"email":"ada@example.com","password":"wrong"}`, "")
+	s.do(t, http.MethodPost, "/api/v1/auth/signout", "", "not-a-jwt")
+
+	resp, err := s.app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+
+	for _, want := range []string{
+		`figbase_http_requests_total{method="POST",route="/api/v1/auth/signup",status="200"}`,
+		`figbase_http_request_duration_seconds_bucket{method="POST",route="/api/v1/auth/signin",status="400"`,
+		`figbase_auth_events_total{event="signup",result="success"}`,
+		`figbase_auth_events_total{event="signin",result="failure"}`,
+		`figbase_token_validation_failures_total{reason="malformed"}`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("metrics have no %s", want)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	// Record spans in memory, restore the globals afterwards.
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	if _, err := tracing.Setup(context.Background(), &config.Tracing{Exporter: "none"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t)
+	s.signUp(t, "ada@example.com", "correct horse")
+
+	// Continue the caller's trace from the traceparent header.
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", strings.NewReader(`{"email":"ada@example.com","password":"correct horse"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	server, ok := spans["POST /api/v1/auth/signin"]
+	if !ok {
+		t.Fatalf("no server span in the caller's trace, got %v", spans)
+	}
+	for _, name := range []string{"password.compare", "token.generate"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if span.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("%s span is not a child of the server span", name)
+		}
+	}
+}
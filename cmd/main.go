@@ -1,10 +1,23 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Figbase/api/app/controllers"
 	"github.com/Figbase/api/pkg/middleware"
-	"github.com/Figbase/api/pkg/routes"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/cache"
+	"github.com/Figbase/api/platform/config"
 	"github.com/Figbase/api/platform/database"
-	"github.com/gofiber/fiber/v2"
+	"github.com/Figbase/api/platform/logging"
+	"github.com/Figbase/api/platform/mailer"
+	"github.com/Figbase/api/platform/migrations"
+	"github.com/Figbase/api/platform/tracing"
 )
 
 // @title Figbase API
@@ -15,25 +28,117 @@ import (
 // @contact.email support@figbase.co
 // @license.name Apache 2.0
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
-// @BasePath /api
+// @BasePath /
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
 func main() {
+	// Load and validate config, fail fast on errors.
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("invalid config", err)
+	}
+
+	// Structured logs, levels per component.
+	logging.Setup(&cfg.Log, os.Stdout)
+
+	// Tracing, spans are flushed on shutdown.
+	flushTraces, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		fatal("tracing setup failed", err)
+	}
+
 	// Database connections.
-	database.ConnectDb()
+	if err := database.ConnectDb(&cfg.Database); err != nil {
+		fatal("database connection failed", err)
+	}
+
+	// Run migrate subcommand and exit, if given.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("migrate failed", err)
+		}
+		return
+	}
+
+	// Apply pending migrations, replicas wait on the migration lock.
+	if cfg.Database.MigrateOnStart {
+		if err := runMigrate([]string{"up"}); err != nil {
+			fatal("migrations failed", err)
+		}
+	}
+
+	// Shared Redis client, closed on shutdown.
+	rdb, err := cache.NewClient(&cfg.Redis)
+	if err != nil {
+		fatal("redis connection failed", err)
+	}
 
-	// Define a new Fiber app.
-	app := fiber.New()
+	// Platform and business logic settings.
+	mailer.Configure(&cfg.Mailer)
+	middleware.ConfigureRateLimit(&cfg.RateLimit, middleware.NewRedisRateLimiter(rdb))
+	middleware.ConfigureIdempotency(&cfg.Idempotency, middleware.NewRedisIdempotencyStore(rdb))
+	utils.ConfigureTokens(&cfg.JWT)
 
-	// Middlewares.
-	middleware.FiberMiddleware(app) // Register Fiber's middleware for app.
+	// Stores of the controllers, on Postgres and Redis.
+	db := database.DB.Db
+	handlers := controllers.New(&controllers.Dependencies{
+		App:           &cfg.App,
+		Session:       &cfg.Session,
+		Tx:            repository.NewGormTransactor(db),
+		Users:         repository.NewGormUserRepository(db),
+		Organizations: repository.NewGormOrganizationRepository(db),
+		Invitations:   repository.NewGormInvitationRepository(db),
+		Privacy:       repository.NewGormPrivacyRepository(db),
+		Scim:          repository.NewGormScimRepository(db),
+		Sessions:      repository.NewRedisSessionStore(rdb),
+		Tokens:        repository.NewRedisTokenStore(rdb),
+		Audit:         repository.NewGormAuditStore(db),
+	})
 
-	// Routes.
-	routes.PublicRoutes(app)  // Register public routes for app.
-	routes.PrivateRoutes(app) // Register private routes for app.
-	routes.NotFoundRoute(app) // Register route for 404 Error.
+	// Migrations this build expects, checked by readiness.
+	migrationList, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		fatal("migrations could not be loaded", err)
+	}
+
+	// Dependency checks for readiness.
+	health := controllers.NewHealthController(map[string]controllers.HealthCheck{
+		"postgres": database.Ping,
+		"redis": func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		},
+		"migrations": func(ctx context.Context) error {
+			return database.CheckMigrations(ctx, database.DB.Db, migrationList)
+		},
+	})
+
+	// Define a new Fiber app with middleware and routes.
+	app := newApp(cfg, handlers, health)
+
+	// Start server in background.
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server started", "address", cfg.Server.Address)
+		serverErr <- app.Listen(cfg.Server.Address)
+	}()
+
+	// Wait for a stop signal or for the server to fail.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	select {
+	case sig := <-quit:
+		slog.Info("shutting down", "signal", sig.String())
+	case err := <-serverErr:
+		slog.Error("server is not running", "error", err)
+	}
+
+	// Stop accepting connections and drain in-flight requests and jobs.
+	shutdown(app, health, rdb, flushTraces, &cfg.Server)
+}
 
-	// Start server (with or without graceful shutdown).
-	app.Listen(":3000")
+// fatal func for logging a startup error and exiting.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
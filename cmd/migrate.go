@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Figbase/api/platform/database"
+	"github.com/Figbase/api/platform/migrations"
+)
+
+// migrateUsage is printed for unknown migrate commands.
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate func for the migrate subcommand: up, down [steps] and status.
+func runMigrate(args []string) error {
+	// Read embedded migration files.
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return database.MigrateUp(database.DB.Db, all)
+	case "down":
+		// Roll back one migration, unless more steps are given.
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		return database.MigrateDown(database.DB.Db, all, steps)
+	case "status":
+		states, err := database.MigrationStatus(database.DB.Db, all)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = state.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%06d  %-40s  %s\n", state.Version, state.Name, applied)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
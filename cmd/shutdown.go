@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Figbase/api/app/controllers"
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// shutdown func for stopping the app within the timeout, then closing connection pools.
+// Pools are closed last, so draining requests and jobs can still use them.
+func shutdown(app *fiber.App, health *controllers.HealthController, rdb io.Closer, flushTraces func(context.Context) error, cfg *config.Server) {
+	// Report not ready and give the orchestrator time to stop sending traffic.
+	health.SetReady(false)
+	time.Sleep(cfg.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections, wait for in-flight requests.
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+	}
+
+	// Wait for background jobs, like data exports.
+	if err := controllers.WaitBackground(ctx); err != nil {
+		slog.Error("background jobs did not finish", "error", err)
+	}
+
+	// Close connection pools.
+	if err := database.Close(); err != nil {
+		slog.Error("database close failed", "error", err)
+	}
+	if err := rdb.Close(); err != nil {
+		slog.Error("redis close failed", "error", err)
+	}
+
+	// Flush buffered spans, even if draining used up the timeout.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := flushTraces(flushCtx); err != nil {
+		slog.Error("trace flush failed", "error", err)
+	}
+
+	slog.Info("server stopped")
+}
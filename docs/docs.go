@@ -0,0 +1,17 @@
+// Package docs holds the OpenAPI document of the API, generated from handler annotations.
+// Run go generate ./docs after changing annotations or the types they reference.
+package docs
+
+import _ "embed"
+
+//go:generate go run ../pkg/openapi/gen -root .. -out openapi.json
+
+// OpenAPI is the OpenAPI 3 document of the API.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// SwaggerInitializer starts Swagger UI with the OpenAPI document of the API.
+//
+//go:embed swagger-initializer.js
+var SwaggerInitializer []byte
@@ -0,0 +1,171 @@
+// Package apperror describes errors returned to clients as RFC 7807 problem details,
+// each with a stable code clients can rely on instead of the message.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error struct to describe an error of a request.
+// Detail is shown to the client, Err is only logged.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields map[string]string // invalid fields, for validation errors
+	Err    error
+}
+
+// Error method to describe the error for logs.
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Detail + ": " + e.Err.Error()
+	}
+
+	return e.Code + ": " + e.Detail
+}
+
+// Unwrap method to get the internal cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap method to set the internal cause, which is logged but never shown to the client.
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// New func for creating an error with the status, code and message for the client.
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// BadRequest func for creating a 400 error.
+func BadRequest(code, detail string) *Error {
+	return New(fiber.StatusBadRequest, code, detail)
+}
+
+// Unauthorized func for creating a 401 error.
+func Unauthorized(code, detail string) *Error {
+	return New(fiber.StatusUnauthorized, code, detail)
+}
+
+// Forbidden func for creating a 403 error.
+func Forbidden(code, detail string) *Error {
+	return New(fiber.StatusForbidden, code, detail)
+}
+
+// NotFound func for creating a 404 error.
+func NotFound(code, detail string) *Error {
+	return New(fiber.StatusNotFound, code, detail)
+}
+
+// Conflict func for creating a 409 error.
+func Conflict(code, detail string) *Error {
+	return New(fiber.StatusConflict, code, detail)
+}
+
+// InvalidBody func for creating a 400 error for a body which can't be parsed.
+func InvalidBody(err error) *Error {
+	return BadRequest(CodeInvalidBody, err.Error())
+}
+
+// Validation func for creating a 400 error listing invalid fields.
+func Validation(fields map[string]string) *Error {
+	e := BadRequest(CodeValidationFailed, "request has invalid fields")
+	e.Fields = fields
+	return e
+}
+
+// Internal func for creating a 500 error, the cause is logged and a generic message is shown.
+func Internal(err error) *Error {
+	return New(fiber.StatusInternalServerError, CodeInternal, "internal server error").Wrap(err)
+}
+
+// From func for converting any error: typed errors are kept, Fiber errors keep their
+// status and message, others become internal errors.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		e := New(fiberErr.Code, codeOfStatus(fiberErr.Code), fiberErr.Message)
+		if fiberErr.Code >= fiber.StatusInternalServerError {
+			// Fiber messages of server errors may carry internals.
+			e.Detail = http.StatusText(fiberErr.Code)
+		}
+		return e
+	}
+
+	return Internal(err)
+}
+
+// StatusOf func for getting the response status an error will be written with.
+func StatusOf(err error) int {
+	return From(err).Status
+}
+
+// codeOfStatus func for getting the generic code of a status.
+func codeOfStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusRequestEntityTooLarge:
+		return CodeBodyTooLarge
+	case fiber.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case fiber.StatusTooManyRequests:
+		return CodeRateLimited
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+
+	return CodeBadRequest
+}
+
+// Problem struct to describe an error response, as RFC 7807 problem details.
+// See: https://www.rfc-editor.org/rfc/rfc7807
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// Problem method to describe the error for the client, without its internal cause.
+func (e *Error) Problem(instance, requestID string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
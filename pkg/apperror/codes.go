@@ -0,0 +1,56 @@
+package apperror
+
+// Stable error codes. Clients may rely on them, so codes are never renamed or reused.
+const (
+	// Generic codes.
+	CodeBadRequest           = "bad_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeRouteNotFound        = "route_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+
+	// Idempotency keys.
+	CodeIdempotencyKeyInvalid = "idempotency_key_invalid"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+
+	// Authentication and tokens.
+	CodeTokenMissing       = "token_missing"
+	CodeTokenInvalid       = "token_invalid"
+	CodeTokenExpired       = "token_expired"
+	CodeSessionEnded       = "session_ended"
+	CodePermissionDenied   = "permission_denied"
+	CodeEmailNotFound      = "email_not_found"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeWrongPassword      = "wrong_password"
+	CodeAccountBlocked     = "account_blocked"
+	CodeCSRFInvalid        = "csrf_invalid"
+
+	// Users and accounts.
+	CodeEmailTaken       = "email_taken"
+	CodeUserNotFound     = "user_not_found"
+	CodeInvalidID        = "invalid_id"
+	CodeSelfModification = "self_modification"
+	CodeInvalidRole      = "invalid_role"
+
+	// Organizations and invitations.
+	CodeOrganizationNotFound = "organization_not_found"
+	CodeInvitationNotFound   = "invitation_not_found"
+	CodeInvitationInvalid    = "invitation_invalid"
+	CodeInvitationMismatch   = "invitation_email_mismatch"
+	CodeInvitationExists     = "invitation_exists"
+
+	// Email changes and data exports.
+	CodeConfirmationInvalid = "confirmation_invalid"
+	CodeExportNotFound      = "export_not_found"
+	CodeExportNotReady      = "export_not_ready"
+)
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/platform/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccessLog func for logging each request with its route, status and latency.
+// Server errors are logged as errors, client errors as warnings. Query strings and
+// headers are not logged, so tokens in them never reach the logs.
+func AccessLog() func(*fiber.Ctx) error {
+	log := logging.For("http")
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		// Status of an error not yet written by the error handler.
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperror.StatusOf(err)
+		}
+
+		// Requests caught by the 404 handler have no route.
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("route", route),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+			slog.Int("bytes", len(c.Response().Body())),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		log.LogAttrs(c.UserContext(), level, "request", attrs...)
+
+		return err
+	}
+}
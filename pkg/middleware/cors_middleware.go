@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// corsAllowHeaders are the request headers browsers may send from allowed origins.
+var corsAllowHeaders = []string{
+	fiber.HeaderAuthorization,
+	fiber.HeaderContentType,
+	fiber.HeaderXRequestID,
+	idempotencyKeyHeader,
+	utils.CSRFTokenHeader,
+}
+
+// corsExposeHeaders are the response headers scripts of allowed origins may read.
+var corsExposeHeaders = []string{
+	fiber.HeaderXRequestID,
+	fiber.HeaderRetryAfter,
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"Idempotent-Replayed",
+}
+
+// CORS func for letting browsers call the API from the allowed origins only.
+// Without allowed origins, cross-origin requests get no CORS headers, so browsers block them.
+func CORS(cfg *config.CORS) func(*fiber.Ctx) error {
+	if len(cfg.AllowOrigins) == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowOrigins, ","),
+		AllowHeaders:     strings.Join(corsAllowHeaders, ","),
+		ExposeHeaders:    strings.Join(corsExposeHeaders, ","),
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CSRF func for protecting requests authenticated by the session cookie, in the cookie session mode.
+// It uses the double-submit pattern: a script of the web app reads the CSRF cookie and repeats it
+// in the X-CSRF-Token header, which other sites can't do. The cookie is issued on safe requests.
+// Requests without the session cookie are authenticated by headers, browsers never send those by
+// themselves, so they are not checked.
+func CSRF(cfg *config.Session) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if cfg.Mode != utils.SessionModeCookie {
+			return c.Next()
+		}
+
+		token := c.Cookies(utils.CSRFTokenCookie)
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+			if token == "" {
+				cookie, err := utils.NewCSRFCookie(cfg)
+				if err != nil {
+					// Return status 500 and error message.
+					return apperror.Internal(err)
+				}
+				c.Cookie(cookie)
+			}
+			return c.Next()
+		}
+
+		if c.Cookies(utils.RefreshTokenCookie) == "" {
+			return c.Next()
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(c.Get(utils.CSRFTokenHeader)), []byte(token)) != 1 {
+			// Return status 403 and error message.
+			return apperror.Forbidden(apperror.CodeCSRFInvalid, "missing or invalid CSRF token")
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/platform/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// problemContentType is the media type of error responses.
+const problemContentType = "application/problem+json"
+
+// ErrorHandler func for writing errors returned by handlers as problem details.
+// Server errors are logged with their cause, the client only gets a generic message.
+// See: https://docs.gofiber.io/guide/error-handling
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	appErr := apperror.From(err)
+
+	if appErr.Status >= fiber.StatusInternalServerError {
+		logging.For("http").ErrorContext(c.UserContext(), "request failed",
+			"code", appErr.Code,
+			"error", err,
+		)
+	}
+
+	requestID, _ := c.Locals("request_id").(string)
+
+	// Return status and problem details.
+	return c.Status(appErr.Status).JSON(appErr.Problem(c.Path(), requestID), problemContentType)
+}
+
+// Recover func for turning a panic of a handler into a 500 error, logged with its stack.
+func Recover() func(*fiber.Ctx) error {
+	return recover.New(recover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
+			logging.For("http").ErrorContext(c.UserContext(), "panic",
+				"panic", e,
+				slog.String("stack", string(debug.Stack())),
+			)
+		},
+	})
+}
@@ -1,18 +1,29 @@
 package middleware
 
 import (
+	"github.com/Figbase/api/platform/config"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 // FiberMiddleware provide Fiber's built-in middlewares.
 // See: https://docs.gofiber.io/api/middleware
-func FiberMiddleware(a *fiber.App) {
+func FiberMiddleware(a *fiber.App, cfg *config.Config) {
 	a.Use(
-		// Add CORS to each route.
-		cors.New(),
-		// Add simple logger.
-		logger.New(),
+		// Add a request ID to each request and response.
+		RequestID(),
+		// Add a trace span to each request.
+		Tracing(),
+		// Add security headers to each response, errors included.
+		SecurityHeaders(&cfg.Server),
+		// Add CORS for the allowed origins.
+		CORS(&cfg.CORS),
+		// Add structured access log.
+		AccessLog(),
+		// Add request metrics.
+		Metrics(),
+		// Turn panics into 500 errors, inside logs and metrics so they are counted.
+		Recover(),
+		// Check CSRF tokens of requests sending the session cookie.
+		CSRF(&cfg.Session),
 	)
 }
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader carries the key a client sends again with each retry of a request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKeyMaxLength is the longest key accepted, UUIDs are expected.
+const idempotencyKeyMaxLength = 255
+
+// idempotentHeaders are the response headers replayed with a kept response.
+var idempotentHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+// idempotencyConfig and idempotencyStore are set by ConfigureIdempotency on startup.
+var (
+	idempotencyConfig = &config.Idempotency{}
+	idempotencyStore  IdempotencyStore
+)
+
+// ConfigureIdempotency func for setting how long responses are kept and the storage keeping them.
+func ConfigureIdempotency(cfg *config.Idempotency, store IdempotencyStore) {
+	idempotencyConfig = cfg
+	idempotencyStore = store
+}
+
+// Idempotency func for making retries of a route safe: the first response to a request with
+// an Idempotency-Key is kept and replayed to retries with the same key and payload.
+// Keys are scoped per user, a key sent again with another payload is refused with 422, and
+// a retry arriving while the first request is in flight is refused with 409.
+// Error responses are not kept, so a failed request can be retried with the same key.
+func Idempotency() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(idempotencyKeyHeader)
+		if key == "" || !idempotencyConfig.Enabled || idempotencyStore == nil {
+			return c.Next()
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			// Return status 400 and error message.
+			return apperror.BadRequest(apperror.CodeIdempotencyKeyInvalid, "Idempotency-Key must be at most 255 characters")
+		}
+
+		// Keys are hashed, so they never reach Redis in plain text.
+		storeKey := "idempotency:" + idempotencyScope(c) + ":" + utils.HashToken(key)
+		requestHash := utils.HashToken(c.Method() + " " + c.OriginalURL() + "\n" + string(c.Body()))
+		lock := uuid.NewString()
+
+		kept, err := idempotencyStore.Lock(c.UserContext(), storeKey, &IdempotencyRecord{
+			Lock:        lock,
+			RequestHash: requestHash,
+		}, idempotencyConfig.LockTimeout)
+		if err != nil {
+			// Return status 503, handling the request could duplicate it.
+			return apperror.New(fiber.StatusServiceUnavailable, apperror.CodeUnavailable, "idempotency keys are unavailable, try again later").Wrap(err)
+		}
+
+		switch {
+		case kept == nil:
+			// The request holds the key, handle it below.
+		case kept.RequestHash != requestHash:
+			metrics.IdempotentRequests.WithLabelValues("key_reused").Inc()
+
+			// Return status 422 and error message.
+			return apperror.New(fiber.StatusUnprocessableEntity, apperror.CodeIdempotencyKeyReused, "Idempotency-Key was already used for another request")
+		case kept.InFlight():
+			metrics.IdempotentRequests.WithLabelValues("in_flight").Inc()
+			c.Set(fiber.HeaderRetryAfter, "1")
+
+			// Return status 409 and error message.
+			return apperror.Conflict(apperror.CodeIdempotencyInProgress, "a request with this Idempotency-Key is in progress, retry later")
+		default:
+			metrics.IdempotentRequests.WithLabelValues("replayed").Inc()
+			for name, value := range kept.Headers {
+				c.Set(name, value)
+			}
+			for _, cookie := range kept.Cookies {
+				c.Response().Header.Add(fiber.HeaderSetCookie, cookie)
+			}
+			c.Set("Idempotent-Replayed", "true")
+
+			// Return the kept response.
+			return c.Status(kept.Status).Send(kept.Body)
+		}
+
+		// The response is kept even if the client went away meanwhile.
+		ctx := context.WithoutCancel(c.UserContext())
+
+		err = c.Next()
+		if status := c.Response().StatusCode(); err != nil || status >= fiber.StatusBadRequest {
+			if unlockErr := idempotencyStore.Unlock(ctx, storeKey, lock); unlockErr != nil {
+				slog.WarnContext(ctx, "idempotency key unlock failed", "error", unlockErr)
+			}
+			return err
+		}
+
+		record := &IdempotencyRecord{
+			RequestHash: requestHash,
+			Status:      c.Response().StatusCode(),
+			Headers:     map[string]string{},
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}
+		for _, name := range idempotentHeaders {
+			if value := c.GetRespHeader(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		// Cookies are replayed too, a retry must get the session the first response set.
+		c.Response().Header.VisitAllCookie(func(_, value []byte) {
+			record.Cookies = append(record.Cookies, string(value))
+		})
+		if err := idempotencyStore.Save(ctx, storeKey, lock, record, idempotencyConfig.TTL); err != nil {
+			// The response is sent anyway, a retry would be handled again.
+			slog.WarnContext(ctx, "idempotent response was not kept", "error", err)
+		}
+
+		return nil
+	}
+}
+
+// idempotencyScope func for getting whose keys a request uses: the authenticated user,
+// or all anonymous clients, like sign ups from a phone changing networks.
+func idempotencyScope(c *fiber.Ctx) string {
+	if scope := KeyByUserID(c); strings.HasPrefix(scope, "user:") {
+		return scope
+	}
+
+	return "anonymous"
+}
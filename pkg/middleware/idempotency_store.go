@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyRecord struct to describe a request kept under an idempotency key.
+// Status is 0 while the request is in flight, the response is kept once it is done.
+type IdempotencyRecord struct {
+	Lock        string            `json:"lock,omitempty"` // owner of an in-flight request
+	RequestHash string            `json:"request_hash"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Cookies     []string          `json:"cookies,omitempty"` // Set-Cookie headers, like the refresh token cookie
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// InFlight method to report if the request of the record is still being handled.
+func (r *IdempotencyRecord) InFlight() bool {
+	return r.Status == 0
+}
+
+// IdempotencyStore interface to describe storage of responses by idempotency key.
+// A key is locked by the first request, others get the record kept under it.
+type IdempotencyStore interface {
+	// Lock method to keep the in-flight record under the key, if the key is free.
+	// Otherwise the record already kept is returned.
+	Lock(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Save method to keep the response of the request holding the lock.
+	Save(ctx context.Context, key, lock string, record *IdempotencyRecord, ttl time.Duration) error
+	// Unlock method to free the key held by the request, so it can be retried.
+	Unlock(ctx context.Context, key, lock string) error
+}
+
+var (
+	// idempotencyLockScript sets the key if it is free, or returns the value kept under it.
+	idempotencyLockScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current then
+	return current
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return false
+`)
+
+	// idempotencySaveScript replaces the in-flight value by the response, if the lock is still held.
+	// An empty response deletes the key.
+	idempotencySaveScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current or cjson.decode(current).lock ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == "" then
+	redis.call("DEL", KEYS[1])
+else
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return 1
+`)
+)
+
+// RedisIdempotencyStore struct to keep responses in Redis, so retries may reach any replica.
+type RedisIdempotencyStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisIdempotencyStore func for creating an idempotency store on Redis.
+func NewRedisIdempotencyStore(client redis.UniversalClient) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+// Lock method to keep the in-flight record under the key in one atomic script.
+func (s *RedisIdempotencyStore) Lock(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := idempotencyLockScript.Run(ctx, s.client, []string{key}, value, ttl.Milliseconds()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	kept := &IdempotencyRecord{}
+	if err := json.Unmarshal([]byte(current), kept); err != nil {
+		return nil, err
+	}
+
+	return kept, nil
+}
+
+// Save method to keep the response, if the lock was not lost meanwhile.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key, lock string, record *IdempotencyRecord, ttl time.Duration) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return idempotencySaveScript.Run(ctx, s.client, []string{key}, lock, value, ttl.Milliseconds()).Err()
+}
+
+// Unlock method to delete the key, if the lock was not lost meanwhile.
+func (s *RedisIdempotencyStore) Unlock(ctx context.Context, key, lock string) error {
+	return idempotencySaveScript.Run(ctx, s.client, []string{key}, lock, "", 0).Err()
+}
+
+// memoryIdempotencySweepSize is the number of keys kept before expired ones are removed.
+const memoryIdempotencySweepSize = 10000
+
+// memoryIdempotencyRecord struct to describe a record kept in memory, with its expiration.
+type memoryIdempotencyRecord struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// MemoryIdempotencyStore struct to keep responses in memory, for tests and local runs.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore func for creating an empty in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]memoryIdempotencyRecord{}}
+}
+
+// Lock method to keep the in-flight record under the key, like the Redis script does.
+func (s *MemoryIdempotencyStore) Lock(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if kept, ok := s.records[key]; ok && now.Before(kept.expiresAt) {
+		current := kept.record
+		return &current, nil
+	}
+
+	// Forget expired keys.
+	if len(s.records) >= memoryIdempotencySweepSize {
+		for k, kept := range s.records {
+			if !now.Before(kept.expiresAt) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	s.records[key] = memoryIdempotencyRecord{record: *record, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+// Save method to keep the response, if the lock is still held.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key, lock string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kept, ok := s.records[key]; ok && kept.record.Lock == lock && time.Now().Before(kept.expiresAt) {
+		s.records[key] = memoryIdempotencyRecord{record: *record, expiresAt: time.Now().Add(ttl)}
+	}
+
+	return nil
+}
+
+// Unlock method to delete the key, if the lock is still held.
+func (s *MemoryIdempotencyStore) Unlock(_ context.Context, key, lock string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kept, ok := s.records[key]; ok && kept.record.Lock == lock {
+		delete(s.records, key)
+	}
+
+	return nil
+}
@@ -1,9 +1,14 @@
 package middleware
 
 import (
-	"os"
+	"errors"
 
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/logging"
+	"github.com/Figbase/api/platform/metrics"
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 
 	jwtMiddleware "github.com/gofiber/contrib/jwt"
 )
@@ -13,26 +18,65 @@ import (
 func JWTProtected() func(*fiber.Ctx) error {
 	// Create config for JWT authentication middleware.
 	config := jwtMiddleware.Config{
-		SigningKey:   jwtMiddleware.SigningKey{Key: []byte(os.Getenv("JWT_SECRET_KEY"))},
-		ContextKey:   "jwt", // used in private routes
-		ErrorHandler: jwtError,
+		KeyFunc:        utils.JWTKeyFunc,
+		ContextKey:     "jwt", // used in private routes
+		SuccessHandler: jwtSuccess,
+		ErrorHandler:   jwtError,
 	}
 
 	return jwtMiddleware.New(config)
 }
 
+// jwtSuccess func for logging the authenticated user ID with each record of the request
+// and limiting requests of the user on private routes.
+func jwtSuccess(c *fiber.Ctx) error {
+	if token, ok := c.Locals("jwt").(*jwt.Token); ok {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if id, ok := claims["id"].(string); ok {
+				c.SetUserContext(logging.WithUserID(c.UserContext(), id))
+			}
+		}
+	}
+
+	if limited, err := applyRateLimit(c, "private", KeyByUserID); limited {
+		return err
+	}
+
+	return c.Next()
+}
+
 func jwtError(c *fiber.Ctx, err error) error {
-	// Return status 401 and failed authentication error.
-	if err.Error() == "Missing or malformed JWT" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-		})
+	// Count rejected token by reason.
+	metrics.TokenValidationFailures.WithLabelValues(jwtErrorReason(err)).Inc()
+
+	// Return status 400 and missing token error.
+	if errors.Is(err, jwtMiddleware.ErrJWTMissingOrMalformed) {
+		return apperror.BadRequest(apperror.CodeTokenMissing, "missing or malformed JWT")
+	}
+
+	// Return status 401 and expired token error.
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return apperror.Unauthorized(apperror.CodeTokenExpired, "token has expired")
 	}
 
 	// Return status 401 and failed authentication error.
-	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"status":  "error",
-		"message": err.Error(),
-	})
+	return apperror.Unauthorized(apperror.CodeTokenInvalid, "token is invalid")
+}
+
+// jwtErrorReason func for getting a bounded metric label for a token error.
+func jwtErrorReason(err error) string {
+	switch {
+	case errors.Is(err, jwtMiddleware.ErrJWTMissingOrMalformed):
+		return "missing"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return "invalid_signature"
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "unverifiable"
+	case errors.Is(err, jwt.ErrTokenExpired), errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "expired"
+	default:
+		return "invalid"
+	}
 }
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics func for counting requests and observing their latency.
+// Requests are labelled by route pattern, not path, to keep label values bounded.
+func Metrics() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		err := c.Next()
+
+		// Status of an error not yet written by the error handler.
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperror.StatusOf(err)
+		}
+
+		// Requests caught by the 404 handler have no route.
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+
+		labels := []string{c.Method(), route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
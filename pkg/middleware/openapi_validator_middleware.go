@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+func init() {
+	// SCIM clients send and receive JSON with their own content type.
+	openapi3filter.RegisterBodyDecoder("application/scim+json", openapi3filter.JSONBodyDecoder)
+}
+
+// OpenAPIValidator func for checking requests against the OpenAPI document before handlers run.
+// Path and query parameters and JSON bodies must match the schemas of the operation, bodies
+// with fields the operation doesn't describe are rejected. Requests of routes missing from
+// the document are left to the router.
+// With validateResponses, JSON responses of handlers are checked too, a response which
+// doesn't match is replaced by a 500 error, so tests catch handlers drifting from the document.
+func OpenAPIValidator(spec []byte, validateResponses bool) func(*fiber.Ctx) error {
+	router, err := openAPIRouter(spec)
+	if err != nil {
+		// The document is embedded and checked by tests, so it only fails on a broken build.
+		panic(fmt.Sprintf("openapi document is invalid: %v", err))
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:          true,
+		SkipSettingDefaults: true,
+		// Tokens are checked by the JWT middleware of routes.
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+
+	return func(c *fiber.Ctx) error {
+		req := &http.Request{}
+		if err := fasthttpadaptor.ConvertRequest(c.Context(), req, true); err != nil {
+			// Return status 400 and error message.
+			return apperror.BadRequest(apperror.CodeBadRequest, "request can't be read")
+		}
+
+		route, pathParams, err := router.FindRoute(req)
+		if err != nil {
+			// Not described routes are handled, or refused, by the router.
+			return c.Next()
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.UserContext(), input); err != nil {
+			// Return status 400 and invalid fields.
+			return requestValidationError(err)
+		}
+
+		if err := c.Next(); err != nil || !validateResponses {
+			return err
+		}
+
+		return validateResponse(c, input)
+	}
+}
+
+// openAPIRouter func for loading the document and finding its operations by request.
+func openAPIRouter(spec []byte) (routers.Router, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, err
+	}
+
+	return legacy.NewRouter(doc)
+}
+
+// requestValidationError func for describing why a request doesn't match the document:
+// bodies of other content types are unsupported, other errors are listed by field.
+func requestValidationError(err error) error {
+	var errs openapi3.MultiError
+	if !errors.As(err, &errs) {
+		errs = openapi3.MultiError{err}
+	}
+
+	fields := map[string]string{}
+	for _, err := range errs {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(err, &reqErr) {
+			return apperror.Internal(err)
+		}
+
+		var parseErr *openapi3filter.ParseError
+		switch {
+		case reqErr.Parameter != nil:
+			fields[reqErr.Parameter.Name] = reasonOf(reqErr)
+		case reqErr.Err == nil && strings.HasPrefix(reqErr.Reason, "header Content-Type"):
+			// Return status 415 and the content types of the operation.
+			return apperror.New(fiber.StatusUnsupportedMediaType, apperror.CodeUnsupportedMediaType,
+				"request body must be one of "+strings.Join(contentTypes(reqErr.RequestBody), ", "))
+		case errors.As(reqErr.Err, &parseErr):
+			// Return status 400, the body is not JSON.
+			return apperror.InvalidBody(parseErr)
+		case errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired):
+			fields["body"] = "request body is required"
+		default:
+			bodyFields(fields, reqErr.Err)
+		}
+	}
+
+	return apperror.Validation(fields)
+}
+
+// bodyFields func for adding schema errors of a body to fields, by their path in the body.
+func bodyFields(fields map[string]string, err error) {
+	var errs openapi3.MultiError
+	if !errors.As(err, &errs) {
+		errs = openapi3.MultiError{err}
+	}
+
+	for _, err := range errs {
+		var schemaErr *openapi3.SchemaError
+		if !errors.As(err, &schemaErr) {
+			fields["body"] = err.Error()
+			continue
+		}
+
+		path := schemaErr.JSONPointer()
+		if schemaErr.SchemaField == "properties" {
+			// Unknown fields are reported on their parent object, name each of them.
+			object, _ := schemaErr.Value.(map[string]interface{})
+			for key := range object {
+				if _, ok := schemaErr.Schema.Properties[key]; !ok {
+					fields[fieldName(append(path, key))] = "unknown field"
+				}
+			}
+			continue
+		}
+		fields[fieldName(path)] = schemaErr.Reason
+	}
+}
+
+// fieldName func for naming a field by its path in the body, like members.0.value.
+func fieldName(path []string) string {
+	if len(path) == 0 {
+		return "body"
+	}
+
+	return strings.Join(path, ".")
+}
+
+// reasonOf func for describing the error of a parameter, without the schema dump of kin-openapi.
+func reasonOf(reqErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+	if reqErr.Err != nil {
+		return reqErr.Err.Error()
+	}
+
+	return reqErr.Reason
+}
+
+// contentTypes func for listing the content types of a request body, sorted.
+func contentTypes(body *openapi3.RequestBody) []string {
+	var result []string
+	if body != nil {
+		for contentType := range body.Content {
+			result = append(result, contentType)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// validateResponse func for checking the JSON response written by the handler against the document.
+// Files and other content types are not checked.
+func validateResponse(c *fiber.Ctx, input *openapi3filter.RequestValidationInput) error {
+	mediaType, _, _ := mime.ParseMediaType(string(c.Response().Header.ContentType()))
+	if len(c.Response().Body()) > 0 && mediaType != fiber.MIMEApplicationJSON && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+
+	header := http.Header{}
+	c.Response().Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	output := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 c.Response().StatusCode(),
+		Header:                 header,
+		Options:                input.Options,
+	}
+	output.SetBodyBytes(c.Response().Body())
+
+	if err := openapi3filter.ValidateResponse(c.UserContext(), output); err != nil {
+		// Return status 500, the handler doesn't follow the document.
+		return apperror.Internal(fmt.Errorf("response doesn't match the openapi document: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/pkg/utils"
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rateLimitConfig and rateLimiter are set by ConfigureRateLimit on startup.
+var (
+	rateLimitConfig = &config.RateLimit{}
+	rateLimiter     RateLimiter
+)
+
+// RateLimitKey func to describe what a policy counts requests by.
+// An empty key lets the request through without counting it.
+type RateLimitKey func(c *fiber.Ctx) string
+
+// ConfigureRateLimit func for setting limits per policy and the storage counting requests.
+func ConfigureRateLimit(cfg *config.RateLimit, limiter RateLimiter) {
+	rateLimitConfig = cfg
+	rateLimiter = limiter
+}
+
+// RateLimit func for limiting requests of a route by the named policy, counted per key.
+// Limits of the policy come from config, so they can differ between deployments.
+func RateLimit(policy string, key RateLimitKey) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if limited, err := applyRateLimit(c, policy, key); limited {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// applyRateLimit func for counting the request, it reports true with the error to return
+// if the request must not go on.
+// Headers describe the most restrictive policy of the route.
+func applyRateLimit(c *fiber.Ctx, policy string, key RateLimitKey) (bool, error) {
+	if !rateLimitConfig.Enabled || rateLimiter == nil {
+		return false, nil
+	}
+	limit, period, err := config.ParseRate(rateLimitConfig.Policies[policy])
+	if err != nil || limit == 0 {
+		return false, nil
+	}
+	k := key(c)
+	if k == "" {
+		return false, nil
+	}
+
+	result, err := rateLimiter.Allow(c.UserContext(), "ratelimit:"+policy+":"+k, limit, period)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "rate limit check failed", "policy", policy, "error", err)
+		if rateLimitConfig.FailOpen {
+			return false, nil
+		}
+
+		// Return status 503 and error message.
+		return true, apperror.New(fiber.StatusServiceUnavailable, apperror.CodeUnavailable, "rate limit is unavailable, try again later").Wrap(err)
+	}
+
+	// Describe the policy with the least requests left.
+	if current, err := strconv.Atoi(c.GetRespHeader("RateLimit-Remaining")); err != nil || result.Remaining < current || !result.Allowed {
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	}
+	if result.Allowed {
+		return false, nil
+	}
+
+	metrics.RateLimited.WithLabelValues(policy).Inc()
+	retryAfter := ceilSeconds(result.RetryAfter)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	// Return status 429 and error message.
+	return true, apperror.New(fiber.StatusTooManyRequests, apperror.CodeRateLimited, fmt.Sprintf("too many requests, try again in %d seconds", retryAfter))
+}
+
+// KeyByIP func for counting requests per client IP.
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUserID func for counting requests per authenticated user, or per IP before authentication.
+func KeyByUserID(c *fiber.Ctx) string {
+	if token, ok := c.Locals("jwt").(*jwt.Token); ok {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if id, ok := claims["id"].(string); ok && id != "" {
+				return "user:" + id
+			}
+		}
+	}
+
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey func for counting requests per bearer token or X-API-Key, or per IP without one.
+// Keys are hashed, so they never reach Redis in plain text.
+func KeyByAPIKey(c *fiber.Ctx) string {
+	key := c.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		key = bearer
+	}
+	if key == "" {
+		return KeyByIP(c)
+	}
+
+	return "key:" + utils.HashToken(key)
+}
+
+// KeyByEmail func for counting requests per email in the request body, like sign in attempts
+// on one account from many IPs. Emails are hashed, requests without one are not counted.
+func KeyByEmail(c *fiber.Ctx) string {
+	body := struct {
+		Email string `json:"email" form:"email"`
+	}{}
+	if err := c.BodyParser(&body); err != nil || body.Email == "" {
+		return ""
+	}
+
+	return "email:" + utils.HashToken(strings.ToLower(strings.TrimSpace(body.Email)))
+}
+
+// ceilSeconds func for rounding a duration up to whole seconds, as headers expect.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult struct to describe the decision on one request.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until the next request is allowed, if not allowed
+	ResetAfter time.Duration // until the full limit is available again
+}
+
+// RateLimiter interface to describe storage counting requests per key.
+// Limits use GCRA: limit requests per period, spread evenly, with bursts up to the limit.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error)
+}
+
+// gcraScript keeps the theoretical arrival time (TAT) of the next request, in microseconds.
+// The server clock is used, so replicas with skewed clocks share one view.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local interval = math.floor(period / limit)
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - period
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%d", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// RedisRateLimiter struct to count requests in Redis, so limits hold across replicas.
+type RedisRateLimiter struct {
+	client redis.UniversalClient
+}
+
+// NewRedisRateLimiter func for creating a rate limiter on Redis.
+func NewRedisRateLimiter(client redis.UniversalClient) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client}
+}
+
+// Allow method to count a request of the key in one atomic script.
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit int, period time.Duration) (RateLimitResult, error) {
+	values, err := gcraScript.Run(ctx, l.client, []string{key}, limit, period.Microseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// memoryRateLimiterSweepSize is the number of keys kept before expired ones are removed.
+const memoryRateLimiterSweepSize = 10000
+
+// MemoryRateLimiter struct to count requests in memory, for tests and local runs.
+type MemoryRateLimiter struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewMemoryRateLimiter func for creating an empty in-memory rate limiter.
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{tats: map[string]time.Time{}}
+}
+
+// Allow method to count a request of the key, like the Redis script does.
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, limit int, period time.Duration) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	interval := period / time.Duration(limit)
+
+	tat := l.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-period)
+	if now.Before(allowAt) {
+		return RateLimitResult{
+			Limit:      limit,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}, nil
+	}
+
+	// Forget keys whose limit is fully available again.
+	if len(l.tats) >= memoryRateLimiterSweepSize {
+		for k, t := range l.tats {
+			if t.Before(now) {
+				delete(l.tats, k)
+			}
+		}
+	}
+
+	l.tats[key] = newTat
+	return RateLimitResult{
+		Allowed:    true,
+		Limit:      limit,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}, nil
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/Figbase/api/platform/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the request ID from the client or proxy and back in the response.
+const requestIDHeader = fiber.HeaderXRequestID
+
+// validRequestID matches request IDs safe to log and echo, others are replaced.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+// RequestID func for taking the X-Request-ID header from the caller or generating one.
+// The ID is returned in the response header and logged with every record of the request.
+func RequestID() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(requestIDHeader, id)
+		c.Locals("request_id", id)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/pkg/repository"
+	"github.com/Figbase/api/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScimProtected func for specify SCIM routes group with organization bearer token authentication.
+// Tokens are looked up in the given repository, the authenticated organization ID is stored in the "scim_org" local.
+func ScimProtected(orgs repository.OrganizationRepository) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Limit requests per token before looking it up.
+		if limited, err := applyRateLimit(c, "scim", KeyByAPIKey); limited {
+			return err
+		}
+
+		// Get bearer token from Authorization header.
+		token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || token == "" {
+			return scimUnauthorized(c)
+		}
+
+		// Get SCIM token by its hash.
+		scimToken, err := orgs.GetScimTokenByHash(c.UserContext(), utils.HashToken(token))
+		if err != nil {
+			return scimUnauthorized(c)
+		}
+
+		// Remember last usage of the token.
+		orgs.TouchScimToken(c.UserContext(), scimToken.ID, time.Now())
+
+		c.Locals("scim_org", scimToken.OrganizationID)
+
+		return c.Next()
+	}
+}
+
+func scimUnauthorized(c *fiber.Ctx) error {
+	// Return status 401 and SCIM error.
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(models.ScimError{
+		Schemas: []string{models.ScimErrorSchema},
+		Status:  "401",
+		Detail:  "Missing or invalid SCIM bearer token",
+	}, "application/scim+json")
+}
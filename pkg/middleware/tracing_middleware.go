@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/Figbase/api/platform/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing func for starting a server span for each request.
+// The span continues a trace from the W3C traceparent header and is passed on in the user context.
+func Tracing() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		// Extract trace context from request headers.
+		headers := http.Header{}
+		c.Request().Header.VisitAll(func(key, value []byte) {
+			headers.Add(string(key), string(value))
+		})
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(headers))
+
+		ctx, span := tracing.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// Name span by route pattern, once it is known.
+		// Status of an error not yet written by the error handler.
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperror.StatusOf(err)
+		}
+		if c.Route().Method != "USE" {
+			span.SetName(c.Method() + " " + c.Route().Path)
+			span.SetAttributes(semconv.HTTPRoute(c.Route().Path))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if err != nil && status >= fiber.StatusInternalServerError {
+			span.RecordError(err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,28 @@
+// Command gen writes the OpenAPI document of the API, run it with go generate ./docs.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Figbase/api/pkg/openapi"
+)
+
+func main() {
+	root := flag.String("root", ".", "repository root")
+	out := flag.String("out", "openapi.json", "output file")
+	flag.Parse()
+
+	doc, err := openapi.Generate(*root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := doc.Marshal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,606 @@
+// Package openapi generates an OpenAPI 3 document from the swag annotations of handlers
+// and the Go types they reference, so the spec is written next to the code it describes.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dirs of the repository read by the generator, relative to its root.
+var (
+	// InfoDir holds the general API annotations, on the main func.
+	InfoDir = "cmd"
+
+	// HandlerDirs hold handlers annotated with @Router.
+	HandlerDirs = []string{"app/controllers"}
+
+	// TypeDirs hold types referenced by annotations, like models.User.
+	TypeDirs = []string{"app/models", "pkg/apperror"}
+)
+
+// problemSchema is the error type always written as application/problem+json.
+const problemSchema = "apperror.Problem"
+
+// mimeTypes are the short content types of @Accept and @Produce, like swag has.
+var mimeTypes = map[string]string{
+	"json":                  "application/json",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+}
+
+var (
+	// paramRegexp matches: name in type required "description".
+	paramRegexp = regexp.MustCompile(`^(\S+)\s+(path|query|header|body)\s+(\S+)\s+(true|false)(?:\s+"([^"]*)")?$`)
+
+	// responseRegexp matches: code {kind} type "description".
+	responseRegexp = regexp.MustCompile(`^(\d{3}|default)\s+\{(\w+)\}\s+(\S+)(?:\s+"([^"]*)")?$`)
+
+	// routerRegexp matches: /path [method].
+	routerRegexp = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]$`)
+
+	// pathParamRegexp matches a parameter of a path, like {id}.
+	pathParamRegexp = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// generator struct to keep parsed types while a document is built.
+type generator struct {
+	doc   *Document
+	types map[string]map[string]*ast.TypeSpec // by package name, then type name
+}
+
+// Generate func for building the document from the sources under the repository root.
+func Generate(root string) (*Document, error) {
+	g := &generator{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:         map[string]*Schema{},
+				SecuritySchemes: map[string]*SecurityScheme{},
+			},
+		},
+		types: map[string]map[string]*ast.TypeSpec{},
+	}
+
+	// Index types first, operations reference them.
+	for _, dir := range TypeDirs {
+		pkgs, err := parseDir(filepath.Join(root, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			g.indexTypes(pkg)
+		}
+	}
+
+	pkgs, err := parseDir(filepath.Join(root, InfoDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range pkgs {
+		if err := g.parseInfo(pkg); err != nil {
+			return nil, err
+		}
+	}
+	if g.doc.Info.Title == "" {
+		return nil, fmt.Errorf("openapi: no @title found in %s", InfoDir)
+	}
+
+	tags := map[string]bool{}
+	for _, dir := range HandlerDirs {
+		pkgs, err := parseDir(filepath.Join(root, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			if err := g.parseHandlers(pkg, tags); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name := range tags {
+		g.doc.Tags = append(g.doc.Tags, Tag{Name: name})
+	}
+	sort.Slice(g.doc.Tags, func(i, j int) bool { return g.doc.Tags[i].Name < g.doc.Tags[j].Name })
+
+	return g.doc, nil
+}
+
+// Marshal method to encode the document as indented JSON, the same for the same sources.
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// parseDir func for parsing Go files of a directory with comments, tests are skipped.
+func parseDir(dir string) ([]*ast.Package, error) {
+	notTest := func(info fs.FileInfo) bool { return !strings.HasSuffix(info.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, notTest, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	// Walk packages and files in a fixed order, so output does not change between runs.
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*ast.Package, 0, len(pkgs))
+	for _, name := range names {
+		result = append(result, pkgs[name])
+	}
+
+	return result, nil
+}
+
+// sortedFiles func for getting files of a package ordered by name.
+func sortedFiles(pkg *ast.Package) []*ast.File {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+
+	return files
+}
+
+// annotations func for getting "@Name value" lines of a doc comment, in order.
+func annotations(doc *ast.CommentGroup) [][2]string {
+	if doc == nil {
+		return nil
+	}
+	var result [][2]string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value, _ := strings.Cut(line[1:], " ")
+		result = append(result, [2]string{name, strings.TrimSpace(value)})
+	}
+
+	return result
+}
+
+// indexTypes method to remember type declarations of a package by name.
+func (g *generator) indexTypes(pkg *ast.Package) {
+	if g.types[pkg.Name] == nil {
+		g.types[pkg.Name] = map[string]*ast.TypeSpec{}
+	}
+	for _, file := range sortedFiles(pkg) {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				g.types[pkg.Name][spec.Name.Name] = spec
+			}
+		}
+	}
+}
+
+// parseInfo method to read general API annotations from the main func.
+func (g *generator) parseInfo(pkg *ast.Package) error {
+	for _, file := range sortedFiles(pkg) {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Name.Name != "main" || fn.Recv != nil {
+				continue
+			}
+
+			info := &g.doc.Info
+			var scheme *SecurityScheme
+			for _, a := range annotations(fn.Doc) {
+				switch a[0] {
+				case "title":
+					info.Title = a[1]
+				case "version":
+					info.Version = a[1]
+				case "description":
+					info.Description = a[1]
+				case "termsOfService":
+					info.TermsOfService = a[1]
+				case "contact.name", "contact.email":
+					if info.Contact == nil {
+						info.Contact = &Contact{}
+					}
+					if a[0] == "contact.name" {
+						info.Contact.Name = a[1]
+					} else {
+						info.Contact.Email = a[1]
+					}
+				case "license.name":
+					info.License = &License{Name: a[1]}
+				case "license.url":
+					if info.License == nil {
+						return fmt.Errorf("openapi: @license.url without @license.name")
+					}
+					info.License.URL = a[1]
+				case "BasePath":
+					g.doc.Servers = []Server{{URL: a[1]}}
+				case "securityDefinitions.apikey":
+					scheme = &SecurityScheme{Type: "apiKey"}
+					g.doc.Components.SecuritySchemes[a[1]] = scheme
+				case "in", "name":
+					if scheme == nil {
+						return fmt.Errorf("openapi: @%s without @securityDefinitions", a[0])
+					}
+					if a[0] == "in" {
+						scheme.In = a[1]
+					} else {
+						scheme.Name = a[1]
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseHandlers method to add an operation for each func annotated with @Router.
+func (g *generator) parseHandlers(pkg *ast.Package, tags map[string]bool) error {
+	for _, file := range sortedFiles(pkg) {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if err := g.parseHandler(pkg.Name, fn, tags); err != nil {
+				return fmt.Errorf("openapi: %s: %w", fn.Name.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseHandler method to add the operation of one handler, if it is annotated.
+func (g *generator) parseHandler(pkgName string, fn *ast.FuncDecl, tags map[string]bool) error {
+	op := &Operation{OperationID: fn.Name.Name, Responses: map[string]*Response{}}
+	accept, produce := []string{"application/json"}, []string{"application/json"}
+	var path, method string
+	var body []*Parameter
+	var bodyRequired bool
+	var responses [][2]string
+
+	for _, a := range annotations(fn.Doc) {
+		switch a[0] {
+		case "Summary":
+			op.Summary = a[1]
+		case "Description":
+			if op.Description != "" {
+				op.Description += "\n"
+			}
+			op.Description += a[1]
+		case "Tags":
+			for _, tag := range strings.Split(a[1], ",") {
+				op.Tags = append(op.Tags, strings.TrimSpace(tag))
+			}
+		case "Accept":
+			accept = mimeList(a[1])
+		case "Produce":
+			produce = mimeList(a[1])
+		case "Security":
+			op.Security = append(op.Security, map[string][]string{a[1]: {}})
+		case "Param":
+			param, err := g.parseParam(pkgName, a[1])
+			if err != nil {
+				return err
+			}
+			if param.In == "body" {
+				body = append(body, param)
+				bodyRequired = bodyRequired || param.Required
+				continue
+			}
+			op.Parameters = append(op.Parameters, param)
+		case "Success", "Failure":
+			responses = append(responses, [2]string{a[0], a[1]})
+		case "Router":
+			m := routerRegexp.FindStringSubmatch(a[1])
+			if m == nil {
+				return fmt.Errorf("invalid @Router %q", a[1])
+			}
+			path, method = m[1], strings.ToLower(m[2])
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	// Path parameters must match the path.
+	declared := map[string]bool{}
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			declared[param.Name] = true
+		}
+	}
+	for _, m := range pathParamRegexp.FindAllStringSubmatch(path, -1) {
+		if !declared[m[1]] {
+			return fmt.Errorf("path parameter %q of %s is not described by @Param", m[1], path)
+		}
+		delete(declared, m[1])
+	}
+	for name := range declared {
+		return fmt.Errorf("@Param %q is not in path %s", name, path)
+	}
+
+	// Body parameters become one request body.
+	if len(body) > 0 {
+		schema, err := bodySchema(body)
+		if err != nil {
+			return err
+		}
+		op.RequestBody = &RequestBody{Required: bodyRequired, Content: content(accept, schema)}
+	}
+
+	for _, r := range responses {
+		code, response, err := g.parseResponse(pkgName, r[1], produce)
+		if err != nil {
+			return err
+		}
+		op.Responses[code] = response
+	}
+	if len(op.Responses) == 0 {
+		return fmt.Errorf("no @Success for %s %s", method, path)
+	}
+	for _, tag := range op.Tags {
+		tags[tag] = true
+	}
+
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = PathItem{}
+	}
+	if _, ok := g.doc.Paths[path][method]; ok {
+		return fmt.Errorf("%s %s is described twice", method, path)
+	}
+	g.doc.Paths[path][method] = op
+
+	return nil
+}
+
+// parseParam method to read a @Param annotation.
+func (g *generator) parseParam(pkgName, value string) (*Parameter, error) {
+	m := paramRegexp.FindStringSubmatch(value)
+	if m == nil {
+		return nil, fmt.Errorf("invalid @Param %q", value)
+	}
+	schema, err := g.typeSchema(pkgName, m[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parameter{
+		Name:        m[1],
+		In:          m[2],
+		Description: m[5],
+		Required:    m[2] == "path" || m[4] == "true",
+		Schema:      schema,
+	}, nil
+}
+
+// parseResponse method to read a @Success or @Failure annotation.
+func (g *generator) parseResponse(pkgName, value string, produce []string) (string, *Response, error) {
+	m := responseRegexp.FindStringSubmatch(value)
+	if m == nil {
+		return "", nil, fmt.Errorf("invalid response %q", value)
+	}
+	code, kind, typeName, description := m[1], m[2], m[3], m[4]
+	if description == "" {
+		status, _ := strconv.Atoi(code)
+		description = http.StatusText(status)
+	}
+	if description == "" {
+		description = "error"
+	}
+	response := &Response{Description: description}
+
+	// No content has no body to describe.
+	if code == strconv.Itoa(http.StatusNoContent) {
+		return code, response, nil
+	}
+
+	var schema *Schema
+	switch kind {
+	case "object":
+		s, err := g.typeSchema(pkgName, typeName)
+		if err != nil {
+			return "", nil, err
+		}
+		schema = s
+	case "array":
+		items, err := g.typeSchema(pkgName, typeName)
+		if err != nil {
+			return "", nil, err
+		}
+		schema = &Schema{Type: "array", Items: items}
+	case "file":
+		schema = &Schema{Type: "string", Format: "binary"}
+	case "string", "integer", "number", "boolean":
+		schema = &Schema{Type: kind}
+	default:
+		return "", nil, fmt.Errorf("unsupported response kind {%s}", kind)
+	}
+
+	// Problem details always have their own content type.
+	if typeName == problemSchema {
+		produce = []string{"application/problem+json"}
+	}
+	response.Content = content(produce, schema)
+
+	return code, response, nil
+}
+
+// typeSchema method to get the schema of a type named in an annotation.
+// Besides Go and JSON type names it reads []T for arrays, *T for values which may be null,
+// and swag's composition T{field=type,...} for envelopes with typed fields.
+func (g *generator) typeSchema(pkgName, name string) (*Schema, error) {
+	if base, fields, ok := strings.Cut(name, "{"); ok {
+		return g.composedSchema(pkgName, base, fields)
+	}
+	if items, ok := strings.CutPrefix(name, "[]"); ok {
+		schema, err := g.typeSchema(pkgName, items)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: schema}, nil
+	}
+	if elem, ok := strings.CutPrefix(name, "*"); ok {
+		schema, err := g.typeSchema(pkgName, elem)
+		if err != nil {
+			return nil, err
+		}
+		if schema.Ref != "" {
+			// References can't be nullable in OpenAPI 3.0.
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}, nil
+		}
+		schema.Nullable = true
+		return schema, nil
+	}
+
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}, nil
+	case "int", "integer":
+		return &Schema{Type: "integer"}, nil
+	case "number":
+		return &Schema{Type: "number"}, nil
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}, nil
+	case "object":
+		return &Schema{Type: "object"}, nil
+	}
+
+	pkg, typ, found := strings.Cut(name, ".")
+	if !found {
+		pkg, typ = pkgName, name
+	}
+
+	return g.refSchema(pkg, typ)
+}
+
+// composedSchema method to get the schema of a composition, like models.Response{user=models.User}:
+// the base type with more fields, or an object of those fields when the base is object.
+func (g *generator) composedSchema(pkgName, base, fields string) (*Schema, error) {
+	fields, ok := strings.CutSuffix(fields, "}")
+	if !ok {
+		return nil, fmt.Errorf("invalid type %s{%s", base, fields)
+	}
+
+	extra := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range splitFields(fields) {
+		key, typeName, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q of %s", field, base)
+		}
+		property, err := g.typeSchema(pkgName, typeName)
+		if err != nil {
+			return nil, err
+		}
+		extra.Properties[key] = property
+	}
+	if base == "object" {
+		return extra, nil
+	}
+
+	schema, err := g.typeSchema(pkgName, base)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{AllOf: []*Schema{schema, extra}}, nil
+}
+
+// splitFields func for splitting fields of a composition by the commas outside of nested braces.
+func splitFields(fields string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i, r := range fields {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, fields[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(result, fields[start:])
+}
+
+// bodySchema func for merging body parameters into one schema.
+// A single parameter of an object type is the body itself, others are the only properties allowed.
+func bodySchema(params []*Parameter) (*Schema, error) {
+	if len(params) == 1 && params[0].Schema.Ref != "" {
+		return params[0].Schema, nil
+	}
+
+	// Fields which are not described are rejected, so clients can't set fields like user_role.
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for _, param := range params {
+		if param.Schema.Ref != "" {
+			return nil, fmt.Errorf("body parameter %q of an object type must be the only one", param.Name)
+		}
+		property := *param.Schema
+		property.Description = param.Description
+		schema.Properties[param.Name] = &property
+		if param.Required {
+			schema.Required = append(schema.Required, param.Name)
+		}
+	}
+
+	return schema, nil
+}
+
+// content func for describing one schema in each content type.
+func content(types []string, schema *Schema) map[string]*MediaType {
+	result := make(map[string]*MediaType, len(types))
+	for _, t := range types {
+		result[t] = &MediaType{Schema: schema}
+	}
+
+	return result
+}
+
+// mimeList func for expanding a comma-separated @Accept or @Produce list.
+func mimeList(value string) []string {
+	var result []string
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if full, ok := mimeTypes[t]; ok {
+			t = full
+		}
+		result = append(result, t)
+	}
+
+	return result
+}
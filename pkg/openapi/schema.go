@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// externalTypes are types of other modules used by models, described by their JSON form.
+var externalTypes = map[string]Schema{
+	"time.Time":       {Type: "string", Format: "date-time"},
+	"uuid.UUID":       {Type: "string", Format: "uuid"},
+	"gorm.DeletedAt":  {Type: "string", Format: "date-time", Nullable: true},
+	"json.RawMessage": {},
+}
+
+// basicTypes are the Go types with a JSON counterpart.
+var basicTypes = map[string]Schema{
+	"string":  {Type: "string"},
+	"bool":    {Type: "boolean"},
+	"int":     {Type: "integer"},
+	"int8":    {Type: "integer"},
+	"int16":   {Type: "integer"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"int64":   {Type: "integer", Format: "int64"},
+	"uint":    {Type: "integer"},
+	"uint8":   {Type: "integer"},
+	"uint16":  {Type: "integer"},
+	"uint32":  {Type: "integer", Format: "int32"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+	"any":     {},
+}
+
+// refSchema method to get a reference to the component schema of a type,
+// the component is added on first use.
+func (g *generator) refSchema(pkg, name string) (*Schema, error) {
+	key := pkg + "." + name
+	ref := &Schema{Ref: "#/components/schemas/" + key}
+	if _, ok := g.doc.Components.Schemas[key]; ok {
+		return ref, nil
+	}
+
+	spec, ok := g.types[pkg][name]
+	if !ok {
+		return nil, fmt.Errorf("type %s is not found in %v", key, TypeDirs)
+	}
+
+	// Reserve the name first, types may refer to themselves.
+	g.doc.Components.Schemas[key] = &Schema{}
+	schema, err := g.exprSchema(pkg, spec.Type)
+	if err != nil {
+		delete(g.doc.Components.Schemas, key)
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	g.doc.Components.Schemas[key] = schema
+
+	return ref, nil
+}
+
+// exprSchema method to get the schema of a Go type expression of the package.
+func (g *generator) exprSchema(pkg string, expr ast.Expr) (*Schema, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicTypes[t.Name]; ok {
+			return &basic, nil
+		}
+		return g.refSchema(pkg, t.Name)
+
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T", t.X)
+		}
+		name := x.Name + "." + t.Sel.Name
+		if external, ok := externalTypes[name]; ok {
+			return &external, nil
+		}
+		return g.refSchema(x.Name, t.Sel.Name)
+
+	case *ast.StarExpr:
+		schema, err := g.exprSchema(pkg, t.X)
+		if err != nil || schema.Ref != "" {
+			// References can't be nullable in OpenAPI 3.0.
+			return schema, err
+		}
+		schema.Nullable = true
+		return schema, nil
+
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.exprSchema(pkg, t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case *ast.MapType:
+		values, err := g.exprSchema(pkg, t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case *ast.InterfaceType:
+		return &Schema{}, nil
+
+	case *ast.StructType:
+		return g.structSchema(pkg, t)
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// structSchema method to get the object schema of a struct, by its JSON tags.
+// Fields required by the validator are required, other rules are described where
+// OpenAPI has a counterpart.
+func (g *generator) structSchema(pkg string, t *ast.StructType) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range t.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			value, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(value)
+		}
+		name, opts, _ := strings.Cut(tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		// Embedded structs add their fields, like encoding/json does.
+		if len(field.Names) == 0 && name == "" {
+			embedded, err := g.exprSchema(pkg, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			embedded = g.resolve(embedded)
+			for key, property := range embedded.Properties {
+				schema.Properties[key] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			key := name
+			if key == "" {
+				key = ident.Name
+			}
+
+			property, err := g.exprSchema(pkg, field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+			if override := tag.Get("swaggertype"); override != "" {
+				property = &Schema{Type: override, Nullable: property.Nullable}
+			}
+			// Fields left out when empty may be missing, even if the validator requires them on input.
+			omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+			if required := applyValidation(property, tag.Get("validate")); required && !omitEmpty {
+				schema.Required = append(schema.Required, key)
+			}
+			schema.Properties[key] = property
+		}
+	}
+
+	return schema, nil
+}
+
+// resolve method to get the component a schema refers to.
+func (g *generator) resolve(schema *Schema) *Schema {
+	if key, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/"); ok {
+		return g.doc.Components.Schemas[key]
+	}
+
+	return schema
+}
+
+// applyValidation func for describing validator rules of a field in its schema.
+// It reports if the field is required.
+func applyValidation(schema *Schema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			required = true
+		}
+		if schema.Ref != "" {
+			// Rules of other types are described by their own fields.
+			continue
+		}
+		switch name {
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "lte", "max":
+			if n, err := strconv.Atoi(param); err == nil && schema.Type == "string" {
+				schema.MaxLength = &n
+			}
+		case "min":
+			if n, err := strconv.Atoi(param); err == nil && schema.Type == "array" {
+				schema.MinItems = &n
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.Atoi(value); err == nil && schema.Type == "integer" {
+					schema.Enum = append(schema.Enum, n)
+				} else {
+					schema.Enum = append(schema.Enum, value)
+				}
+			}
+		}
+	}
+
+	return required
+}
@@ -0,0 +1,6 @@
+package repository
+
+const (
+	// AuditReadCredential const for read and export the security audit log.
+	AuditReadCredential string = "audit:read"
+)
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditLockID is the Postgres advisory lock key held while appending,
+// so concurrent appends link to the latest event one after another.
+const auditLockID int64 = 7306154972461310178
+
+// AuditStore interface to describe the append-only storage of audit events.
+type AuditStore interface {
+	// Append stores the event, setting its ID, time and hashes.
+	Append(ctx context.Context, event *models.AuditEvent) error
+	// List returns events matching the filter, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, error)
+	// Verify checks the hash chain, it returns the number of checked events
+	// and a *ChainError for the first event which doesn't match.
+	Verify(ctx context.Context) (int64, error)
+}
+
+// AuditFilter struct to describe a query of audit events, zero fields don't filter.
+type AuditFilter struct {
+	ActorID  *uuid.UUID
+	TargetID *uuid.UUID
+	UserID   *uuid.UUID // actor or target
+	Action   string
+	Outcome  string
+	From     time.Time // created at or after
+	To       time.Time // created before
+	Before   int64     // ID cursor, events with a lower ID
+	Limit    int       // 0 means no limit
+}
+
+// ChainError struct to describe an audit event whose hash doesn't match the chain.
+type ChainError struct {
+	EventID int64
+}
+
+// Error method to describe the broken link.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain is broken at event %d", e.EventID)
+}
+
+// GormAuditStore struct to store audit events in the database.
+// The audit_events table rejects updates and deletes.
+type GormAuditStore struct {
+	db *gorm.DB
+}
+
+// NewGormAuditStore func for creating an audit store on the database.
+func NewGormAuditStore(db *gorm.DB) *GormAuditStore {
+	return &GormAuditStore{db: db}
+}
+
+// Append method to link the event to the latest one and insert it.
+func (s *GormAuditStore) Append(ctx context.Context, event *models.AuditEvent) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Wait for concurrent appends, the lock is released on commit.
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, auditLockID).Error; err != nil {
+			return err
+		}
+
+		var prevHash string
+		if err := tx.Raw(`SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&prevHash).Error; err != nil {
+			return err
+		}
+
+		chainEvent(event, prevHash)
+		return tx.Create(event).Error
+	})
+}
+
+// List method to find events by the filter.
+func (s *GormAuditStore) List(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, error) {
+	query := s.db.WithContext(ctx).Model(&models.AuditEvent{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("(actor_id = ? OR target_id = ?)", *filter.UserID, *filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Before > 0 {
+		query = query.Where("id < ?", filter.Before)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	events := []models.AuditEvent{}
+	if err := query.Order("id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Verify method to recompute the chain from the first event, in batches.
+func (s *GormAuditStore) Verify(ctx context.Context) (int64, error) {
+	var checked int64
+	prevHash := ""
+	batch := []models.AuditEvent{}
+
+	result := s.db.WithContext(ctx).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := checkLink(&batch[i], prevHash); err != nil {
+				return err
+			}
+			prevHash = batch[i].Hash
+			checked++
+		}
+		return nil
+	})
+
+	return checked, result.Error
+}
+
+// chainEvent func for setting time and hashes of a new event following prevHash.
+func chainEvent(event *models.AuditEvent, prevHash string) {
+	// Postgres keeps microseconds, the hash must survive a round trip.
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if event.Metadata == nil {
+		event.Metadata = models.AuditMetadata{}
+	}
+	event.PrevHash = prevHash
+	event.Hash = auditHash(event, prevHash)
+}
+
+// checkLink func for checking the event follows prevHash and its hash matches its content.
+func checkLink(event *models.AuditEvent, prevHash string) error {
+	if event.PrevHash != prevHash || event.Hash != auditHash(event, prevHash) {
+		return &ChainError{EventID: event.ID}
+	}
+
+	return nil
+}
+
+// auditHash func for hashing the content of the event together with the previous hash.
+// The ID is left out, it is only known after insert.
+func auditHash(event *models.AuditEvent, prevHash string) string {
+	// Fields in fixed order, metadata keys are sorted by encoding/json.
+	content, _ := json.Marshal([]interface{}{
+		prevHash,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		event.ActorID,
+		event.TargetID,
+		event.Action,
+		event.Outcome,
+		event.IP,
+		event.UserAgent,
+		event.Metadata,
+	})
+
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
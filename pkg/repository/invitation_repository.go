@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationRepository interface to describe storage of organization invitations.
+type InvitationRepository interface {
+	// Save stores the invitation, inserting or replacing it, it returns ErrDuplicate if another one is pending for the email.
+	Save(ctx context.Context, invitation *models.Invitation) error
+	// GetByID returns the invitation with the ID or ErrNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error)
+	// FindPending returns the pending invitation of the email to the organization or ErrNotFound.
+	FindPending(ctx context.Context, orgID uuid.UUID, email string) (*models.Invitation, error)
+	// ListPending returns pending invitations of the organization, newest first.
+	ListPending(ctx context.Context, orgID uuid.UUID) ([]models.Invitation, error)
+	// ListForUser returns invitations sent by the user or to the email, newest first.
+	ListForUser(ctx context.Context, userID uuid.UUID, email string) ([]models.Invitation, error)
+	// RevokeForEmail revokes all invitations sent to the email and replaces it with the given one.
+	RevokeForEmail(ctx context.Context, email, replacement string) error
+}
+
+// GormInvitationRepository struct to store invitations in the database.
+type GormInvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormInvitationRepository func for creating an invitation repository on the database.
+func NewGormInvitationRepository(db *gorm.DB) *GormInvitationRepository {
+	return &GormInvitationRepository{db: db}
+}
+
+// Save method to insert or update an invitation.
+func (r *GormInvitationRepository) Save(ctx context.Context, invitation *models.Invitation) error {
+	err := gormConn(ctx, r.db).Save(invitation).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+// GetByID method to find an invitation by ID.
+func (r *GormInvitationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Invitation, error) {
+	return r.first(gormConn(ctx, r.db).Where("id = ?", id))
+}
+
+// FindPending method to find the pending invitation of the email to the organization.
+func (r *GormInvitationRepository) FindPending(ctx context.Context, orgID uuid.UUID, email string) (*models.Invitation, error) {
+	return r.first(gormConn(ctx, r.db).Where(
+		"organization_id = ? AND LOWER(email) = ? AND status = ?",
+		orgID, strings.ToLower(email), models.InvitationStatusPending,
+	))
+}
+
+// ListPending method to find pending invitations of the organization.
+func (r *GormInvitationRepository) ListPending(ctx context.Context, orgID uuid.UUID) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	err := gormConn(ctx, r.db).
+		Where("organization_id = ? AND status = ?", orgID, models.InvitationStatusPending).
+		Order("created_at DESC").
+		Find(&invitations).Error
+
+	return invitations, err
+}
+
+// ListForUser method to find invitations sent by or to the user.
+func (r *GormInvitationRepository) ListForUser(ctx context.Context, userID uuid.UUID, email string) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	err := gormConn(ctx, r.db).
+		Where("invited_by = ? OR LOWER(email) = ?", userID, strings.ToLower(email)).
+		Order("created_at DESC").
+		Find(&invitations).Error
+
+	return invitations, err
+}
+
+// RevokeForEmail method to revoke invitations sent to the email and replace the address.
+func (r *GormInvitationRepository) RevokeForEmail(ctx context.Context, email, replacement string) error {
+	return gormConn(ctx, r.db).Model(&models.Invitation{}).
+		Where("LOWER(email) = ?", strings.ToLower(email)).
+		Updates(map[string]interface{}{
+			"email":  replacement,
+			"status": models.InvitationStatusRevoked,
+		}).Error
+}
+
+// first method to get the first invitation of the query.
+func (r *GormInvitationRepository) first(query *gorm.DB) (*models.Invitation, error) {
+	invitation := &models.Invitation{}
+	if err := query.First(invitation).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Figbase/api/app/models"
+)
+
+// MemoryAuditStore struct to store audit events in memory, for tests and local runs.
+type MemoryAuditStore struct {
+	mu     sync.RWMutex
+	events []models.AuditEvent
+}
+
+// NewMemoryAuditStore func for creating an empty in-memory audit store.
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+// Append method to link the event to the latest one and add it.
+func (s *MemoryAuditStore) Append(_ context.Context, event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prevHash := ""
+	if len(s.events) > 0 {
+		prevHash = s.events[len(s.events)-1].Hash
+	}
+
+	event.ID = int64(len(s.events) + 1)
+	chainEvent(event, prevHash)
+	s.events = append(s.events, *event)
+
+	return nil
+}
+
+// List method to find events by the filter.
+func (s *MemoryAuditStore) List(_ context.Context, filter AuditFilter) ([]models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := []models.AuditEvent{}
+	for i := len(s.events) - 1; i >= 0; i-- {
+		e := s.events[i]
+		switch {
+		case filter.ActorID != nil && (e.ActorID == nil || *e.ActorID != *filter.ActorID),
+			filter.TargetID != nil && (e.TargetID == nil || *e.TargetID != *filter.TargetID),
+			filter.UserID != nil && !(e.ActorID != nil && *e.ActorID == *filter.UserID) && !(e.TargetID != nil && *e.TargetID == *filter.UserID),
+			filter.Action != "" && e.Action != filter.Action,
+			filter.Outcome != "" && e.Outcome != filter.Outcome,
+			!filter.From.IsZero() && e.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && !e.CreatedAt.Before(filter.To),
+			filter.Before > 0 && e.ID >= filter.Before:
+			continue
+		}
+
+		events = append(events, e)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// Verify method to recompute the chain from the first event.
+func (s *MemoryAuditStore) Verify(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prevHash := ""
+	for i := range s.events {
+		if err := checkLink(&s.events[i], prevHash); err != nil {
+			return int64(i), err
+		}
+		prevHash = s.events[i].Hash
+	}
+
+	return int64(len(s.events)), nil
+}
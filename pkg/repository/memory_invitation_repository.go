@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+)
+
+// MemoryInvitationRepository struct to store invitations in memory, for tests and local runs.
+type MemoryInvitationRepository struct {
+	mu          sync.RWMutex
+	invitations map[uuid.UUID]models.Invitation
+}
+
+// NewMemoryInvitationRepository func for creating an empty in-memory invitation repository.
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{invitations: map[uuid.UUID]models.Invitation{}}
+}
+
+// Save method to insert or update an invitation.
+func (r *MemoryInvitationRepository) Save(_ context.Context, invitation *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same rule as the database: one pending invitation per email and organization.
+	if invitation.Status == models.InvitationStatusPending {
+		for id, other := range r.invitations {
+			if id != invitation.ID && other.Status == models.InvitationStatusPending &&
+				other.OrganizationID == invitation.OrganizationID && strings.EqualFold(other.Email, invitation.Email) {
+				return ErrDuplicate
+			}
+		}
+	}
+
+	r.invitations[invitation.ID] = *invitation
+
+	return nil
+}
+
+// GetByID method to find an invitation by ID.
+func (r *MemoryInvitationRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &invitation, nil
+}
+
+// FindPending method to find the pending invitation of the email to the organization.
+func (r *MemoryInvitationRepository) FindPending(_ context.Context, orgID uuid.UUID, email string) (*models.Invitation, error) {
+	invitations := r.list(func(invitation *models.Invitation) bool {
+		return invitation.OrganizationID == orgID &&
+			invitation.Status == models.InvitationStatusPending &&
+			strings.EqualFold(invitation.Email, email)
+	})
+	if len(invitations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &invitations[0], nil
+}
+
+// ListPending method to find pending invitations of the organization.
+func (r *MemoryInvitationRepository) ListPending(_ context.Context, orgID uuid.UUID) ([]models.Invitation, error) {
+	return r.list(func(invitation *models.Invitation) bool {
+		return invitation.OrganizationID == orgID && invitation.Status == models.InvitationStatusPending
+	}), nil
+}
+
+// ListForUser method to find invitations sent by or to the user.
+func (r *MemoryInvitationRepository) ListForUser(_ context.Context, userID uuid.UUID, email string) ([]models.Invitation, error) {
+	return r.list(func(invitation *models.Invitation) bool {
+		return invitation.InvitedBy == userID || strings.EqualFold(invitation.Email, email)
+	}), nil
+}
+
+// RevokeForEmail method to revoke invitations sent to the email and replace the address.
+func (r *MemoryInvitationRepository) RevokeForEmail(_ context.Context, email, replacement string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, invitation := range r.invitations {
+		if strings.EqualFold(invitation.Email, email) {
+			invitation.Email = replacement
+			invitation.Status = models.InvitationStatusRevoked
+			r.invitations[id] = invitation
+		}
+	}
+
+	return nil
+}
+
+// list method to get invitations matching the predicate, newest first.
+func (r *MemoryInvitationRepository) list(match func(invitation *models.Invitation) bool) []models.Invitation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, invitation := range r.invitations {
+		if match(&invitation) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.After(invitations[j].CreatedAt) })
+
+	return invitations
+}
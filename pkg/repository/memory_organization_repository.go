@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+)
+
+// MemoryOrganizationRepository struct to store organizations in memory, for tests and local runs.
+type MemoryOrganizationRepository struct {
+	mu          sync.RWMutex
+	orgs        map[uuid.UUID]models.Organization
+	memberships map[uuid.UUID]models.Membership
+	scimTokens  map[uuid.UUID]models.ScimToken // by organization ID
+}
+
+// NewMemoryOrganizationRepository func for creating an empty in-memory organization repository.
+func NewMemoryOrganizationRepository() *MemoryOrganizationRepository {
+	return &MemoryOrganizationRepository{
+		orgs:        map[uuid.UUID]models.Organization{},
+		memberships: map[uuid.UUID]models.Membership{},
+		scimTokens:  map[uuid.UUID]models.ScimToken{},
+	}
+}
+
+// Create method to store a new organization.
+func (r *MemoryOrganizationRepository) Create(_ context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orgs[org.ID]; ok {
+		return ErrDuplicate
+	}
+	r.orgs[org.ID] = *org
+
+	return nil
+}
+
+// GetByID method to find an organization by ID.
+func (r *MemoryOrganizationRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &org, nil
+}
+
+// ListOwned method to find organizations created by the user.
+func (r *MemoryOrganizationRepository) ListOwned(_ context.Context, ownerID uuid.UUID) ([]models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, org := range r.orgs {
+		if org.OwnerID == ownerID {
+			orgs = append(orgs, org)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].CreatedAt.Before(orgs[j].CreatedAt) })
+
+	return orgs, nil
+}
+
+// CreateMembership method to store a new membership.
+func (r *MemoryOrganizationRepository) CreateMembership(_ context.Context, membership *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same rules as the database: one membership per user and organization.
+	for _, existing := range r.memberships {
+		if existing.ID == membership.ID ||
+			existing.OrganizationID == membership.OrganizationID && existing.UserID == membership.UserID {
+			return ErrDuplicate
+		}
+	}
+	r.memberships[membership.ID] = *membership
+
+	return nil
+}
+
+// GetMembership method to find the membership of the user in the organization.
+func (r *MemoryOrganizationRepository) GetMembership(_ context.Context, orgID, userID uuid.UUID) (*models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, membership := range r.memberships {
+		if membership.OrganizationID == orgID && membership.UserID == userID {
+			return &membership, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// ListMemberships method to find memberships by the filter.
+func (r *MemoryOrganizationRepository) ListMemberships(_ context.Context, filter MembershipFilter) ([]models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userIDs := map[uuid.UUID]bool{}
+	for _, id := range filter.UserIDs {
+		userIDs[id] = true
+	}
+
+	memberships := []models.Membership{}
+	for _, membership := range r.memberships {
+		switch {
+		case filter.OrganizationID != uuid.Nil && membership.OrganizationID != filter.OrganizationID,
+			filter.UserID != uuid.Nil && membership.UserID != filter.UserID,
+			filter.UserIDs != nil && !userIDs[membership.UserID],
+			filter.Role != "" && membership.Role != filter.Role:
+			continue
+		}
+		memberships = append(memberships, membership)
+	}
+	sort.Slice(memberships, func(i, j int) bool { return memberships[i].CreatedAt.Before(memberships[j].CreatedAt) })
+
+	return memberships, nil
+}
+
+// UpdateMembership method to save all fields of the membership.
+func (r *MemoryOrganizationRepository) UpdateMembership(_ context.Context, membership *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.memberships[membership.ID] = *membership
+
+	return nil
+}
+
+// DeleteMembership method to delete a membership.
+func (r *MemoryOrganizationRepository) DeleteMembership(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.memberships, id)
+
+	return nil
+}
+
+// DeleteUserMemberships method to delete all memberships of the user.
+func (r *MemoryOrganizationRepository) DeleteUserMemberships(_ context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, membership := range r.memberships {
+		if membership.UserID == userID {
+			delete(r.memberships, id)
+		}
+	}
+
+	return nil
+}
+
+// ReplaceScimToken method to set the SCIM token of the organization.
+func (r *MemoryOrganizationRepository) ReplaceScimToken(_ context.Context, token *models.ScimToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scimTokens[token.OrganizationID] = *token
+
+	return nil
+}
+
+// GetScimTokenByHash method to find a SCIM token by its hash.
+func (r *MemoryOrganizationRepository) GetScimTokenByHash(_ context.Context, hash string) (*models.ScimToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.scimTokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// TouchScimToken method to update the last usage time of the SCIM token.
+func (r *MemoryOrganizationRepository) TouchScimToken(_ context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for orgID, token := range r.scimTokens {
+		if token.ID == id {
+			token.LastUsedAt = &usedAt
+			r.scimTokens[orgID] = token
+		}
+	}
+
+	return nil
+}
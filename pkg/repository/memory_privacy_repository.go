@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+)
+
+// MemoryPrivacyRepository struct to store data exports and tombstones in memory, for tests and local runs.
+type MemoryPrivacyRepository struct {
+	mu         sync.RWMutex
+	exports    map[uuid.UUID]models.DataExport
+	tombstones map[uuid.UUID]models.ErasureTombstone // by user ID
+}
+
+// NewMemoryPrivacyRepository func for creating an empty in-memory privacy repository.
+func NewMemoryPrivacyRepository() *MemoryPrivacyRepository {
+	return &MemoryPrivacyRepository{
+		exports:    map[uuid.UUID]models.DataExport{},
+		tombstones: map[uuid.UUID]models.ErasureTombstone{},
+	}
+}
+
+// CreateExport method to store a new data export.
+func (r *MemoryPrivacyRepository) CreateExport(_ context.Context, export *models.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.exports[export.ID]; ok {
+		return ErrDuplicate
+	}
+	r.exports[export.ID] = *export
+
+	return nil
+}
+
+// GetExport method to find a data export by ID.
+func (r *MemoryPrivacyRepository) GetExport(_ context.Context, id uuid.UUID) (*models.DataExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	export, ok := r.exports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &export, nil
+}
+
+// FindPendingExport method to find the data export of the user being prepared.
+func (r *MemoryPrivacyRepository) FindPendingExport(_ context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, export := range r.exports {
+		if export.UserID == userID && export.Status == models.DataExportStatusPending {
+			return &export, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// UpdateExport method to save all fields of the data export.
+func (r *MemoryPrivacyRepository) UpdateExport(_ context.Context, export *models.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports[export.ID] = *export
+
+	return nil
+}
+
+// FailExport method to mark a pending data export failed.
+func (r *MemoryPrivacyRepository) FailExport(_ context.Context, id uuid.UUID, reason string, failedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	export, ok := r.exports[id]
+	if !ok || export.Status != models.DataExportStatusPending {
+		return nil
+	}
+	export.Status = models.DataExportStatusFailed
+	export.Error = reason
+	export.CompletedAt = &failedAt
+	r.exports[id] = export
+
+	return nil
+}
+
+// DeleteUserExports method to delete all data exports of the user.
+func (r *MemoryPrivacyRepository) DeleteUserExports(_ context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, export := range r.exports {
+		if export.UserID == userID {
+			delete(r.exports, id)
+		}
+	}
+
+	return nil
+}
+
+// CreateTombstone method to store an erasure tombstone.
+func (r *MemoryPrivacyRepository) CreateTombstone(_ context.Context, tombstone *models.ErasureTombstone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tombstones[tombstone.UserID]; ok {
+		return ErrDuplicate
+	}
+	r.tombstones[tombstone.UserID] = *tombstone
+
+	return nil
+}
+
+// GetTombstone method to find the erasure tombstone of the user.
+func (r *MemoryPrivacyRepository) GetTombstone(_ context.Context, userID uuid.UUID) (*models.ErasureTombstone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tombstone, ok := r.tombstones[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &tombstone, nil
+}
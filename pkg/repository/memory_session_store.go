@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemorySessionStore struct to store sessions in memory, for tests and local runs.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]string
+}
+
+// NewMemorySessionStore func for creating an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[uuid.UUID]string{}}
+}
+
+// Save method to set the refresh token of the user.
+func (s *MemorySessionStore) Save(_ context.Context, userID uuid.UUID, refreshToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[userID] = refreshToken
+
+	return nil
+}
+
+// Get method to get the refresh token of the user.
+func (s *MemorySessionStore) Get(_ context.Context, userID uuid.UUID) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.sessions[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return token, nil
+}
+
+// Delete method to delete the refresh token of the user.
+func (s *MemorySessionStore) Delete(_ context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, userID)
+
+	return nil
+}
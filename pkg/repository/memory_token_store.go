@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryToken struct to describe a stored value with its expiration.
+type memoryToken struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryTokenStore struct to store tokens in memory, for tests and local runs.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]memoryToken
+}
+
+// NewMemoryTokenStore func for creating an empty in-memory token store.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]memoryToken{}}
+}
+
+// Save method to set the value with expiration, zero TTL keeps it forever.
+func (s *MemoryTokenStore) Save(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := memoryToken{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		token.expires = time.Now().Add(ttl)
+	}
+	s.tokens[key] = token
+
+	return nil
+}
+
+// Get method to get the value.
+func (s *MemoryTokenStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.live(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]byte(nil), token.value...), nil
+}
+
+// Delete method to delete the value.
+func (s *MemoryTokenStore) Delete(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.live(key)
+	delete(s.tokens, key)
+
+	return ok, nil
+}
+
+// live method to get the token, if it has not expired, callers hold the lock.
+func (s *MemoryTokenStore) live(key string) (memoryToken, bool) {
+	token, ok := s.tokens[key]
+	if ok && !token.expires.IsZero() && time.Now().After(token.expires) {
+		delete(s.tokens, key)
+		return memoryToken{}, false
+	}
+
+	return token, ok
+}
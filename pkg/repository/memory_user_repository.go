@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepository struct to store users in memory, for tests and local runs.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]models.User
+}
+
+// NewMemoryUserRepository func for creating an empty in-memory user repository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: map[uuid.UUID]models.User{}}
+}
+
+// Create method to store a new user.
+func (r *MemoryUserRepository) Create(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Same rules as the database: unique ID and unique email of live users.
+	if _, ok := r.users[user.ID]; ok {
+		return ErrDuplicate
+	}
+	if r.findEmail(user.Email, uuid.Nil) != nil {
+		return ErrDuplicate
+	}
+
+	r.users[user.ID] = *user
+
+	return nil
+}
+
+// GetByID method to find a user by ID.
+func (r *MemoryUserRepository) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+// GetByIDWithDeleted method to find a user by ID, deleted users included.
+func (r *MemoryUserRepository) GetByIDWithDeleted(_ context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+// GetByEmail method to find a user by email.
+func (r *MemoryUserRepository) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user := r.findEmail(email, uuid.Nil)
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	return user, nil
+}
+
+// List method to find a page of users by the filter.
+func (r *MemoryUserRepository) List(_ context.Context, filter UserFilter) ([]models.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range r.users {
+		switch {
+		case user.DeletedAt.Valid != filter.Deleted,
+			filter.Role != "" && user.UserRole != filter.Role,
+			filter.Status != nil && user.UserStatus != *filter.Status,
+			filter.Email != "" && !strings.Contains(strings.ToLower(user.Email), strings.ToLower(filter.Email)),
+			!filter.CreatedFrom.IsZero() && user.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !user.CreatedAt.Before(filter.CreatedTo):
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+
+	total := int64(len(users))
+	users = users[min(filter.Offset, len(users)):]
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+
+	return users, total, nil
+}
+
+// Update method to save all fields of the user.
+func (r *MemoryUserRepository) Update(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if !user.DeletedAt.Valid && r.findEmail(user.Email, user.ID) != nil {
+		return ErrDuplicate
+	}
+
+	updated := *user
+	updated.CreatedAt = existing.CreatedAt
+	r.users[user.ID] = updated
+
+	return nil
+}
+
+// Delete method to soft delete a user.
+func (r *MemoryUserRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok && !user.DeletedAt.Valid {
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.users[id] = user
+	}
+
+	return nil
+}
+
+// findEmail method to get a copy of the live user with the email, other than the excluded one.
+// Callers hold the lock.
+func (r *MemoryUserRepository) findEmail(email string, excluded uuid.UUID) *models.User {
+	for _, user := range r.users {
+		if user.ID != excluded && !user.DeletedAt.Valid && strings.EqualFold(user.Email, email) {
+			return &user
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository interface to describe storage of organizations, their members and SCIM tokens.
+type OrganizationRepository interface {
+	// Create stores a new organization.
+	Create(ctx context.Context, org *models.Organization) error
+	// GetByID returns the organization with the ID or ErrNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	// ListOwned returns organizations created by the user.
+	ListOwned(ctx context.Context, ownerID uuid.UUID) ([]models.Organization, error)
+
+	// CreateMembership stores a new membership, it returns ErrDuplicate if the user is already a member.
+	CreateMembership(ctx context.Context, membership *models.Membership) error
+	// GetMembership returns the membership of the user in the organization or ErrNotFound.
+	GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error)
+	// ListMemberships returns memberships matching the filter, oldest first.
+	ListMemberships(ctx context.Context, filter MembershipFilter) ([]models.Membership, error)
+	// UpdateMembership saves all fields of the membership.
+	UpdateMembership(ctx context.Context, membership *models.Membership) error
+	// DeleteMembership removes the membership.
+	DeleteMembership(ctx context.Context, id uuid.UUID) error
+	// DeleteUserMemberships removes the user from all organizations.
+	DeleteUserMemberships(ctx context.Context, userID uuid.UUID) error
+
+	// ReplaceScimToken stores the SCIM token, replacing the previous one of the organization.
+	ReplaceScimToken(ctx context.Context, token *models.ScimToken) error
+	// GetScimTokenByHash returns the SCIM token with the hash or ErrNotFound.
+	GetScimTokenByHash(ctx context.Context, hash string) (*models.ScimToken, error)
+	// TouchScimToken sets the last usage time of the SCIM token.
+	TouchScimToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// MembershipFilter struct to describe a query of memberships, zero fields don't filter.
+type MembershipFilter struct {
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	UserIDs        []uuid.UUID // one of the users, an empty but not nil list matches nothing
+	Role           string
+}
+
+// GormOrganizationRepository struct to store organizations in the database.
+type GormOrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormOrganizationRepository func for creating an organization repository on the database.
+func NewGormOrganizationRepository(db *gorm.DB) *GormOrganizationRepository {
+	return &GormOrganizationRepository{db: db}
+}
+
+// Create method to insert a new organization.
+func (r *GormOrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	return gormConn(ctx, r.db).Create(org).Error
+}
+
+// GetByID method to find an organization by ID.
+func (r *GormOrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org := &models.Organization{}
+	if err := gormConn(ctx, r.db).Where("id = ?", id).First(org).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// ListOwned method to find organizations created by the user.
+func (r *GormOrganizationRepository) ListOwned(ctx context.Context, ownerID uuid.UUID) ([]models.Organization, error) {
+	orgs := []models.Organization{}
+	err := gormConn(ctx, r.db).Where("owner_id = ?", ownerID).Order("created_at").Find(&orgs).Error
+
+	return orgs, err
+}
+
+// CreateMembership method to insert a new membership.
+func (r *GormOrganizationRepository) CreateMembership(ctx context.Context, membership *models.Membership) error {
+	err := gormConn(ctx, r.db).Create(membership).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+// GetMembership method to find the membership of the user in the organization.
+func (r *GormOrganizationRepository) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*models.Membership, error) {
+	membership := &models.Membership{}
+	if err := gormConn(ctx, r.db).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(membership).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// ListMemberships method to find memberships by the filter.
+func (r *GormOrganizationRepository) ListMemberships(ctx context.Context, filter MembershipFilter) ([]models.Membership, error) {
+	memberships := []models.Membership{}
+	if filter.UserIDs != nil && len(filter.UserIDs) == 0 {
+		return memberships, nil
+	}
+
+	query := gormConn(ctx, r.db)
+	if filter.OrganizationID != uuid.Nil {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.UserIDs != nil {
+		query = query.Where("user_id IN ?", filter.UserIDs)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	err := query.Order("created_at").Find(&memberships).Error
+
+	return memberships, err
+}
+
+// UpdateMembership method to save all fields of the membership.
+func (r *GormOrganizationRepository) UpdateMembership(ctx context.Context, membership *models.Membership) error {
+	return gormConn(ctx, r.db).Save(membership).Error
+}
+
+// DeleteMembership method to delete a membership.
+func (r *GormOrganizationRepository) DeleteMembership(ctx context.Context, id uuid.UUID) error {
+	return gormConn(ctx, r.db).Where("id = ?", id).Delete(&models.Membership{}).Error
+}
+
+// DeleteUserMemberships method to delete all memberships of the user.
+func (r *GormOrganizationRepository) DeleteUserMemberships(ctx context.Context, userID uuid.UUID) error {
+	return gormConn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.Membership{}).Error
+}
+
+// ReplaceScimToken method to delete the previous SCIM token of the organization and insert the new one.
+func (r *GormOrganizationRepository) ReplaceScimToken(ctx context.Context, token *models.ScimToken) error {
+	return gormConn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", token.OrganizationID).Delete(&models.ScimToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+// GetScimTokenByHash method to find a SCIM token by its hash.
+func (r *GormOrganizationRepository) GetScimTokenByHash(ctx context.Context, hash string) (*models.ScimToken, error) {
+	token := &models.ScimToken{}
+	if err := gormConn(ctx, r.db).Where("token_hash = ?", hash).First(token).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// TouchScimToken method to update the last usage time of the SCIM token.
+func (r *GormOrganizationRepository) TouchScimToken(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return gormConn(ctx, r.db).Model(&models.ScimToken{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PrivacyRepository interface to describe storage of personal data exports and erasure tombstones.
+type PrivacyRepository interface {
+	// CreateExport stores a new data export.
+	CreateExport(ctx context.Context, export *models.DataExport) error
+	// GetExport returns the data export with the ID or ErrNotFound.
+	GetExport(ctx context.Context, id uuid.UUID) (*models.DataExport, error)
+	// FindPendingExport returns the data export of the user being prepared or ErrNotFound.
+	FindPendingExport(ctx context.Context, userID uuid.UUID) (*models.DataExport, error)
+	// UpdateExport saves all fields of the data export.
+	UpdateExport(ctx context.Context, export *models.DataExport) error
+	// FailExport marks the data export failed with the reason, unless it is not pending anymore.
+	FailExport(ctx context.Context, id uuid.UUID, reason string, failedAt time.Time) error
+	// DeleteUserExports removes all data exports of the user.
+	DeleteUserExports(ctx context.Context, userID uuid.UUID) error
+	// CreateTombstone stores the proof of an erasure, it returns ErrDuplicate if the user was erased before.
+	CreateTombstone(ctx context.Context, tombstone *models.ErasureTombstone) error
+	// GetTombstone returns the proof of the user's erasure or ErrNotFound.
+	GetTombstone(ctx context.Context, userID uuid.UUID) (*models.ErasureTombstone, error)
+}
+
+// GormPrivacyRepository struct to store data exports and tombstones in the database.
+type GormPrivacyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPrivacyRepository func for creating a privacy repository on the database.
+func NewGormPrivacyRepository(db *gorm.DB) *GormPrivacyRepository {
+	return &GormPrivacyRepository{db: db}
+}
+
+// CreateExport method to insert a new data export.
+func (r *GormPrivacyRepository) CreateExport(ctx context.Context, export *models.DataExport) error {
+	return gormConn(ctx, r.db).Create(export).Error
+}
+
+// GetExport method to find a data export by ID.
+func (r *GormPrivacyRepository) GetExport(ctx context.Context, id uuid.UUID) (*models.DataExport, error) {
+	return r.firstExport(gormConn(ctx, r.db).Where("id = ?", id))
+}
+
+// FindPendingExport method to find the data export of the user being prepared.
+func (r *GormPrivacyRepository) FindPendingExport(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	return r.firstExport(gormConn(ctx, r.db).Where("user_id = ? AND status = ?", userID, models.DataExportStatusPending))
+}
+
+// UpdateExport method to save all fields of the data export.
+func (r *GormPrivacyRepository) UpdateExport(ctx context.Context, export *models.DataExport) error {
+	return gormConn(ctx, r.db).Save(export).Error
+}
+
+// FailExport method to mark a pending data export failed.
+func (r *GormPrivacyRepository) FailExport(ctx context.Context, id uuid.UUID, reason string, failedAt time.Time) error {
+	return gormConn(ctx, r.db).Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", id, models.DataExportStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.DataExportStatusFailed,
+			"error":        reason,
+			"completed_at": failedAt,
+		}).Error
+}
+
+// DeleteUserExports method to delete all data exports of the user.
+func (r *GormPrivacyRepository) DeleteUserExports(ctx context.Context, userID uuid.UUID) error {
+	return gormConn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.DataExport{}).Error
+}
+
+// CreateTombstone method to insert an erasure tombstone.
+func (r *GormPrivacyRepository) CreateTombstone(ctx context.Context, tombstone *models.ErasureTombstone) error {
+	err := gormConn(ctx, r.db).Create(tombstone).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+// GetTombstone method to find the erasure tombstone of the user.
+func (r *GormPrivacyRepository) GetTombstone(ctx context.Context, userID uuid.UUID) (*models.ErasureTombstone, error) {
+	tombstone := &models.ErasureTombstone{}
+	if err := gormConn(ctx, r.db).Where("user_id = ?", userID).First(tombstone).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return tombstone, nil
+}
+
+// firstExport method to get the first data export of the query.
+func (r *GormPrivacyRepository) firstExport(query *gorm.DB) (*models.DataExport, error) {
+	export := &models.DataExport{}
+	if err := query.First(export).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
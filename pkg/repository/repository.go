@@ -0,0 +1,11 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the record does not exist.
+	ErrNotFound = errors.New("record not found")
+
+	// ErrDuplicate is returned when a unique field is already used by another record.
+	ErrDuplicate = errors.New("record already exists")
+)
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScimRepository interface to describe queries of SCIM provisioning.
+// Filters are SQL conditions built from SCIM filters, so there is no in-memory implementation.
+type ScimRepository interface {
+	// ListUsers returns a page of organization members matching the query, oldest first,
+	// and the number of all matching members.
+	ListUsers(ctx context.Context, query ScimUserQuery) ([]models.User, int64, error)
+	// FilterRoles returns the roles matching the SQL condition on the "role" column, in the given order.
+	FilterRoles(ctx context.Context, roles []string, where string, args []interface{}) ([]string, error)
+	// RoleMembers returns members of the organization with the role, oldest first.
+	RoleMembers(ctx context.Context, orgID uuid.UUID, role string) ([]models.User, error)
+}
+
+// ScimUserQuery struct to describe a query of organization members.
+type ScimUserQuery struct {
+	OrganizationID uuid.UUID
+	Where          string // SQL condition on the users and memberships tables, empty doesn't filter
+	Args           []interface{}
+	Offset         int
+	Count          int // 0 returns only the number of matching members
+}
+
+// GormScimRepository struct to query SCIM resources in the database.
+type GormScimRepository struct {
+	db *gorm.DB
+}
+
+// NewGormScimRepository func for creating a SCIM repository on the database.
+func NewGormScimRepository(db *gorm.DB) *GormScimRepository {
+	return &GormScimRepository{db: db}
+}
+
+// ListUsers method to find a page of organization members.
+func (r *GormScimRepository) ListUsers(ctx context.Context, query ScimUserQuery) ([]models.User, int64, error) {
+	// Only members of the organization are visible.
+	members := gormConn(ctx, r.db).Model(&models.User{}).
+		Joins("JOIN memberships ON memberships.user_id = users.id AND memberships.organization_id = ?", query.OrganizationID)
+	if query.Where != "" {
+		members = members.Where(query.Where, query.Args...)
+	}
+
+	// Make query reusable for count and page.
+	members = members.Session(&gorm.Session{})
+
+	var total int64
+	if err := members.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	if query.Count > 0 {
+		if err := members.Order("users.created_at").Offset(query.Offset).Limit(query.Count).Find(&users).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return users, total, nil
+}
+
+// FilterRoles method to filter the roles in a VALUES table.
+func (r *GormScimRepository) FilterRoles(ctx context.Context, roles []string, where string, args []interface{}) ([]string, error) {
+	values := strings.TrimSuffix(strings.Repeat("(?), ", len(roles)), ", ")
+	params := make([]interface{}, 0, len(roles)+len(args))
+	for _, role := range roles {
+		params = append(params, role)
+	}
+	params = append(params, args...)
+
+	matched := []string{}
+	err := gormConn(ctx, r.db).
+		Raw("SELECT role FROM (VALUES "+values+") AS roles(role) WHERE "+where, params...).
+		Scan(&matched).Error
+
+	return matched, err
+}
+
+// RoleMembers method to find members of the organization with the role.
+func (r *GormScimRepository) RoleMembers(ctx context.Context, orgID uuid.UUID, role string) ([]models.User, error) {
+	users := []models.User{}
+	err := gormConn(ctx, r.db).
+		Joins("JOIN memberships ON memberships.user_id = users.id AND memberships.organization_id = ? AND memberships.role = ?", orgID, role).
+		Order("users.created_at").
+		Find(&users).Error
+
+	return users, err
+}
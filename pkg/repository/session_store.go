@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionStore interface to describe storage of refresh tokens, one session per user.
+type SessionStore interface {
+	// Save stores the refresh token of the user, replacing the previous session.
+	Save(ctx context.Context, userID uuid.UUID, refreshToken string) error
+	// Get returns the refresh token of the user or ErrNotFound.
+	Get(ctx context.Context, userID uuid.UUID) (string, error)
+	// Delete ends the session of the user.
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+// RedisSessionStore struct to store sessions in Redis, keyed by user ID.
+type RedisSessionStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisSessionStore func for creating a session store on Redis.
+func NewRedisSessionStore(client redis.UniversalClient) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+// Save method to set the refresh token of the user.
+func (s *RedisSessionStore) Save(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	return s.client.Set(ctx, userID.String(), refreshToken, 0).Err()
+}
+
+// Get method to get the refresh token of the user.
+func (s *RedisSessionStore) Get(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := s.client.Get(ctx, userID.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+
+	return token, err
+}
+
+// Delete method to delete the refresh token of the user.
+func (s *RedisSessionStore) Delete(ctx context.Context, userID uuid.UUID) error {
+	return s.client.Del(ctx, userID.String()).Err()
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenStore interface to describe storage of short-lived tokens, like pending email changes.
+type TokenStore interface {
+	// Save stores the value under the key until the TTL passes.
+	Save(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Get returns the value under the key or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the key and reports if it existed, so only one caller can consume a token.
+	Delete(ctx context.Context, key string) (bool, error)
+}
+
+// RedisTokenStore struct to store tokens in Redis.
+type RedisTokenStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisTokenStore func for creating a token store on Redis.
+func NewRedisTokenStore(client redis.UniversalClient) *RedisTokenStore {
+	return &RedisTokenStore{client: client}
+}
+
+// Save method to set the value with expiration.
+func (s *RedisTokenStore) Save(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Get method to get the value.
+func (s *RedisTokenStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+
+	return value, err
+}
+
+// Delete method to delete the value.
+func (s *RedisTokenStore) Delete(ctx context.Context, key string) (bool, error) {
+	deleted, err := s.client.Del(ctx, key).Result()
+
+	return deleted > 0, err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor interface to describe running changes of several repositories as one unit.
+type Transactor interface {
+	// Transaction runs fn, repositories called with the context it gets take part in the transaction.
+	// It is rolled back if fn returns an error.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// gormTxKey is the context key of the database transaction in progress.
+type gormTxKey struct{}
+
+// GormTransactor struct to run changes of GORM repositories in one database transaction.
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+// NewGormTransactor func for creating a transactor on the database.
+func NewGormTransactor(db *gorm.DB) *GormTransactor {
+	return &GormTransactor{db: db}
+}
+
+// Transaction method to run fn in a database transaction, nested calls use savepoints.
+func (t *GormTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return gormConn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, gormTxKey{}, tx))
+	})
+}
+
+// gormConn func for getting the transaction in progress of the context, or the database.
+func gormConn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(gormTxKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
+
+// MemoryTransactor struct to run changes of in-memory repositories, for tests and local runs.
+// Changes are applied at once, they are not rolled back on errors.
+type MemoryTransactor struct{}
+
+// NewMemoryTransactor func for creating a transactor for in-memory repositories.
+func NewMemoryTransactor() *MemoryTransactor {
+	return &MemoryTransactor{}
+}
+
+// Transaction method to run fn.
+func (t *MemoryTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,6 @@
+package repository
+
+const (
+	// UserManageCredential const for manage users (list, update, sign out, delete).
+	UserManageCredential string = "user:manage"
+)
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Figbase/api/app/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserRepository interface to describe storage of users.
+// Deleted users are never returned, unless asked for.
+type UserRepository interface {
+	// Create stores a new user, it returns ErrDuplicate if the email is taken.
+	Create(ctx context.Context, user *models.User) error
+	// GetByID returns the user with the ID or ErrNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByIDWithDeleted returns the user with the ID, even if deleted, or ErrNotFound.
+	GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByEmail returns the user with the email, compared case-insensitively, or ErrNotFound.
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// List returns a page of users matching the filter, newest first, and the number of all matching users.
+	List(ctx context.Context, filter UserFilter) ([]models.User, int64, error)
+	// Update saves all fields of the user, deleted or not. It returns ErrDuplicate if the email is taken
+	// and ErrNotFound if the user does not exist.
+	Update(ctx context.Context, user *models.User) error
+	// Delete soft deletes the user.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// UserFilter struct to describe a query of users, zero fields don't filter.
+type UserFilter struct {
+	Role        string
+	Status      *int
+	Email       string    // part of the email, case-insensitive
+	CreatedFrom time.Time // created at or after
+	CreatedTo   time.Time // created before
+	Deleted     bool      // only deleted users instead of live ones
+	Offset      int
+	Limit       int // 0 means no limit
+}
+
+// GormUserRepository struct to store users in the database.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository func for creating a user repository on the database.
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// Create method to insert a new user.
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	err := gormConn(ctx, r.db).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+
+	return err
+}
+
+// GetByID method to find a user by ID.
+func (r *GormUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.first(gormConn(ctx, r.db).Where("id = ?", id))
+}
+
+// GetByIDWithDeleted method to find a user by ID, deleted users included.
+func (r *GormUserRepository) GetByIDWithDeleted(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.first(gormConn(ctx, r.db).Unscoped().Where("id = ?", id))
+}
+
+// GetByEmail method to find a user by email.
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.first(gormConn(ctx, r.db).Where("LOWER(email) = ?", strings.ToLower(email)))
+}
+
+// List method to find a page of users by the filter.
+func (r *GormUserRepository) List(ctx context.Context, filter UserFilter) ([]models.User, int64, error) {
+	query := gormConn(ctx, r.db).Model(&models.User{})
+
+	// Deleted users are listed separately, so they can be restored.
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Role != "" {
+		query = query.Where("user_role = ?", filter.Role)
+	}
+	if filter.Status != nil {
+		query = query.Where("user_status = ?", *filter.Status)
+	}
+	if filter.Email != "" {
+		like := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(filter.Email))
+		query = query.Where("LOWER(email) LIKE ?", "%"+like+"%")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	// Make query reusable for count and page.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page := query.Order("created_at DESC").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		page = page.Limit(filter.Limit)
+	}
+
+	users := []models.User{}
+	if err := page.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// Update method to save all fields of the user.
+func (r *GormUserRepository) Update(ctx context.Context, user *models.User) error {
+	result := gormConn(ctx, r.db).Unscoped().Model(user).Select("*").Omit("id", "created_at").Updates(user)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	} else if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete method to soft delete a user.
+func (r *GormUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return gormConn(ctx, r.db).Where("id = ?", id).Delete(&models.User{}).Error
+}
+
+// first method to get the first user of the query.
+func (r *GormUserRepository) first(query *gorm.DB) (*models.User, error) {
+	user := &models.User{}
+	if err := query.First(user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/Figbase/api/app/controllers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// DocsRoutes func for describe API documentation routes: the OpenAPI document and Swagger UI.
+func DocsRoutes(a *fiber.App) {
+	// Routes for GET method:
+	a.Get("/api/docs", controllers.GetDocs)                                               // open Swagger UI at /api/docs/
+	a.Get("/api/docs/openapi.json", controllers.GetOpenAPI)                               // get OpenAPI 3 document
+	a.Get("/api/docs/swagger-initializer.js", controllers.GetSwaggerInitializer)          // start Swagger UI with the document
+	a.Use("/api/docs", filesystem.New(filesystem.Config{Root: http.FS(swaggerFiles.FS)})) // serve Swagger UI assets
+}
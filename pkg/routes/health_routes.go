@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/Figbase/api/app/controllers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthRoutes func for describe liveness and readiness probes for the orchestrator.
+func HealthRoutes(a *fiber.App, health *controllers.HealthController) {
+	// Routes for GET method:
+	a.Get("/healthz", health.Liveness) // process is alive
+	a.Get("/readyz", health.Readiness) // dependencies are reachable and app is not shutting down
+}
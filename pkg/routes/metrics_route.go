@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsRoute func for describe Prometheus scrape route.
+func MetricsRoute(a *fiber.App) {
+	// Routes for GET method:
+	a.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))) // Prometheus metrics
+}
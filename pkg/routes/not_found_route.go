@@ -1,6 +1,9 @@
 package routes
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/Figbase/api/pkg/apperror"
+	"github.com/gofiber/fiber/v2"
+)
 
 // NotFoundRoute func for describe 404 Error route.
 func NotFoundRoute(a *fiber.App) {
@@ -8,11 +11,8 @@ func NotFoundRoute(a *fiber.App) {
 	a.Use(
 		// Anonymous function.
 		func(c *fiber.Ctx) error {
-			// Return HTTP 404 status and JSON response.
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Sorry, endpoint is not found",
-			})
+			// Return HTTP 404 status and problem details.
+			return apperror.NotFound(apperror.CodeRouteNotFound, "Sorry, endpoint is not found")
 		},
 	)
 }
@@ -11,20 +11,49 @@ const (
 )
 
 // PrivateRoutes func for describe group of private routes.
-func PrivateRoutes(a *fiber.App) {
+func PrivateRoutes(a *fiber.App, handlers *controllers.Controllers) {
 	// Create routes group.
 	route := a.Group("/api/v1")
 
 	// Routes for POST method:
 	// route.Post("/book", middleware.JWTProtected(), controllers.CreateBook)           // create a new book
-	route.Post("/auth/signout", middleware.JWTProtected(), controllers.UserSignOut) // de-authorization user
-	route.Post("/token/renew", middleware.JWTProtected(), controllers.RenewTokens)  // renew Access & Refresh tokens
+	route.Post("/auth/signout", middleware.JWTProtected(), handlers.Auth.UserSignOut)                                                             // de-authorization user
+	route.Post("/token/renew", middleware.JWTProtected(), middleware.RateLimit("token_renew", middleware.KeyByUserID), handlers.Auth.RenewTokens) // renew Access & Refresh tokens
+	route.Post("/orgs", middleware.JWTProtected(), middleware.Idempotency(), handlers.Organizations.CreateOrganization)                           // create a new organization
+	route.Post("/orgs/:id/invitations", middleware.JWTProtected(), middleware.Idempotency(), handlers.Organizations.CreateInvitation)             // invite a new member
+	route.Post("/orgs/:id/invitations/:invitationID/resend", middleware.JWTProtected(), handlers.Organizations.ResendInvitation)                  // re-send a pending invitation
+	route.Post("/orgs/:id/scim-token", middleware.JWTProtected(), middleware.Idempotency(), handlers.Organizations.CreateScimToken)               // issue SCIM bearer token
+	route.Post("/admin/users/:id/signout", middleware.JWTProtected(), handlers.Admin.SignOutUser)                                                 // force sign out of a user
+	route.Post("/admin/users/:id/restore", middleware.JWTProtected(), handlers.Admin.RestoreUser)                                                 // restore a deleted user
+	route.Post("/me/email", middleware.JWTProtected(), handlers.Profile.ChangeEmail)                                                              // request email change
+	route.Post("/me/export", middleware.JWTProtected(), middleware.Idempotency(), handlers.Profile.RequestDataExport)                             // request personal data export
+	route.Post("/me/erase", middleware.JWTProtected(), handlers.Profile.EraseProfile)                                                             // erase own personal data
+	route.Post("/admin/users/:id/erase", middleware.JWTProtected(), handlers.Admin.EraseUser)                                                     // erase personal data of a user
+	route.Post("/invitations/accept", middleware.JWTProtected(), middleware.Idempotency(), handlers.Organizations.AcceptInvitation)               // join an organization
 
 	// route.Post("/api-key", middleware.AuthMiddleware(apiKey), controllers.Home) // renew Access & Refresh tokens
 
+	// Routes for GET method:
+	route.Get("/me", middleware.JWTProtected(), handlers.Profile.GetProfile)                              // get own profile
+	route.Get("/orgs/:id/invitations", middleware.JWTProtected(), handlers.Organizations.GetInvitations)  // list pending invitations
+	route.Get("/admin/users", middleware.JWTProtected(), handlers.Admin.GetUsers)                         // list users
+	route.Get("/admin/users/:id", middleware.JWTProtected(), handlers.Admin.GetUser)                      // get one user by ID
+	route.Get("/me/exports/:id", middleware.JWTProtected(), handlers.Profile.GetDataExport)               // get personal data export status
+	route.Get("/me/exports/:id/download", middleware.JWTProtected(), handlers.Profile.DownloadDataExport) // download personal data export
+	route.Get("/admin/audit", middleware.JWTProtected(), handlers.Admin.GetAuditEvents)                   // list security audit events
+	route.Get("/admin/audit/export", middleware.JWTProtected(), handlers.Admin.ExportAuditEvents)         // export security audit events as CSV or NDJSON
+	route.Get("/admin/audit/verify", middleware.JWTProtected(), handlers.Admin.VerifyAuditEvents)         // verify hash chain of the audit log
+
+	// Routes for PATCH method:
+	route.Patch("/me", middleware.JWTProtected(), handlers.Profile.UpdateProfile)         // update own profile
+	route.Patch("/admin/users/:id", middleware.JWTProtected(), handlers.Admin.UpdateUser) // update one user by ID
+
 	// Routes for PUT method:
 	// route.Put("/book", middleware.JWTProtected(), controllers.UpdateBook) // update one book by ID
 
 	// Routes for DELETE method:
+	route.Delete("/me", middleware.JWTProtected(), handlers.Profile.DeleteProfile)                                          // close own account
+	route.Delete("/orgs/:id/invitations/:invitationID", middleware.JWTProtected(), handlers.Organizations.RevokeInvitation) // revoke a pending invitation
+	route.Delete("/admin/users/:id", middleware.JWTProtected(), handlers.Admin.DeleteUser)                                  // soft delete one user by ID
 	// route.Delete("/book", middleware.JWTProtected(), controllers.DeleteBook) // delete one book by ID
 }
@@ -2,11 +2,12 @@ package routes
 
 import (
 	"github.com/Figbase/api/app/controllers"
+	"github.com/Figbase/api/pkg/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
 // PublicRoutes func for describe group of public routes.
-func PublicRoutes(a *fiber.App) {
+func PublicRoutes(a *fiber.App, auth *controllers.AuthController) {
 	// Create routes group.
 	route := a.Group("/api/v1")
 
@@ -15,6 +16,7 @@ func PublicRoutes(a *fiber.App) {
 	// route.Get("/book/:id", controllers.GetBook) // get one book by ID
 
 	// Routes for POST method:
-	route.Post("/auth/signup", controllers.UserSignUp) // register a new user
-	route.Post("/auth/signin", controllers.UserSignIn) // auth, return Access & Refresh tokens
+	route.Post("/auth/signup", middleware.RateLimit("signup", middleware.KeyByIP), middleware.Idempotency(), auth.UserSignUp)                                    // register a new user
+	route.Post("/auth/signin", middleware.RateLimit("signin", middleware.KeyByIP), middleware.RateLimit("signin_email", middleware.KeyByEmail), auth.UserSignIn) // auth, return Access & Refresh tokens
+	route.Post("/auth/email/confirm", middleware.RateLimit("email_confirm", middleware.KeyByIP), auth.ConfirmEmail)                                              // confirm email change, return Access & Refresh tokens
 }
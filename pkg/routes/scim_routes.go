@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/Figbase/api/app/controllers"
+	"github.com/Figbase/api/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ScimRoutes func for describe group of SCIM 2.0 provisioning routes.
+func ScimRoutes(a *fiber.App, scim *controllers.ScimController) {
+	// Create routes group.
+	route := a.Group("/scim/v2")
+
+	// Authenticate with the organization's SCIM token.
+	protected := middleware.ScimProtected(scim.Organizations)
+
+	// Routes for GET method:
+	route.Get("/ServiceProviderConfig", controllers.ScimServiceProviderConfig) // get supported SCIM features
+	route.Get("/Schemas", controllers.ScimSchemas)                             // get supported SCIM schemas
+	route.Get("/Users", protected, scim.ScimGetUsers)                          // list users of the organization
+	route.Get("/Users/:id", protected, scim.ScimGetUser)                       // get one user by ID
+	route.Get("/Groups", protected, scim.ScimGetGroups)                        // list organization roles
+	route.Get("/Groups/:id", protected, scim.ScimGetGroup)                     // get one organization role
+
+	// Routes for POST method:
+	route.Post("/Users", protected, scim.ScimCreateUser) // provision a new user
+
+	// Routes for PUT method:
+	route.Put("/Users/:id", protected, scim.ScimReplaceUser) // replace user attributes
+
+	// Routes for PATCH method:
+	route.Patch("/Users/:id", protected, scim.ScimPatchUser)   // update user attributes
+	route.Patch("/Groups/:id", protected, scim.ScimPatchGroup) // change role members
+
+	// Routes for DELETE method:
+	route.Delete("/Users/:id", protected, scim.ScimDeleteUser) // deprovision user
+}
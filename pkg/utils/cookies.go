@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/Figbase/api/platform/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionModeCookie is the session mode keeping the refresh token in an HttpOnly cookie.
+const SessionModeCookie = "cookie"
+
+const (
+	// RefreshTokenCookie holds the refresh token in the cookie session mode, out of reach of scripts.
+	RefreshTokenCookie = "refresh_token"
+
+	// RefreshTokenCookiePath scopes the refresh token cookie to the token routes, it is sent nowhere else.
+	RefreshTokenCookiePath = "/api/v1/token"
+
+	// CSRFTokenCookie holds the token scripts of the web app repeat in CSRFTokenHeader.
+	CSRFTokenCookie = "csrf_token"
+
+	// CSRFTokenHeader carries the CSRF token on requests sending the refresh token cookie.
+	CSRFTokenHeader = "X-CSRF-Token"
+)
+
+// NewCSRFCookie func for creating a cookie with a new random CSRF token.
+// It is readable by scripts, the web app repeats it in CSRFTokenHeader.
+func NewCSRFCookie(cfg *config.Session) (*fiber.Cookie, error) {
+	token, err := GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fiber.Cookie{
+		Name:     CSRFTokenCookie,
+		Value:    token,
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		Secure:   cfg.CookieSecure,
+		HTTPOnly: false,
+		SameSite: cfg.CookieSameSite,
+	}, nil
+}
+
+// NewRefreshTokenCookie func for creating the HttpOnly cookie keeping the refresh token until it expires.
+func NewRefreshTokenCookie(cfg *config.Session, token string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    token,
+		Path:     RefreshTokenCookiePath,
+		Domain:   cfg.CookieDomain,
+		Expires:  expires,
+		Secure:   cfg.CookieSecure,
+		HTTPOnly: true,
+		SameSite: cfg.CookieSameSite,
+	}
+}
+
+// ClearRefreshTokenCookie func for creating an expired refresh token cookie, browsers delete it.
+func ClearRefreshTokenCookie(cfg *config.Session) *fiber.Cookie {
+	return NewRefreshTokenCookie(cfg, "", time.Unix(0, 0))
+}
@@ -19,6 +19,8 @@ func GetCredentialsByRole(role string) ([]string, error) {
 			repository.AppCreateCredential,
 			repository.AppUpdateCredential,
 			repository.AppDeleteCredential,
+			repository.UserManageCredential,
+			repository.AuditReadCredential,
 		}
 	case repository.ModeratorRoleName:
 		// Moderator credentials (only some access).
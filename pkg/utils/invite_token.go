@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// InviteTokenMetadata struct to describe metadata in an invite token.
+type InviteTokenMetadata struct {
+	InvitationID uuid.UUID
+	Expires      int64
+}
+
+// InviteExpiresAt func for getting expiration time of a new invite token.
+func InviteExpiresAt() time.Time {
+	// Set expires hours count for invite key from config.
+	return time.Now().Add(time.Hour * time.Duration(tokenConfig.InviteExpireHours))
+}
+
+// GenerateInviteToken func for generate a new signed invite token.
+func GenerateInviteToken(invitationID string, expires time.Time) (string, error) {
+	// Create a new claims.
+	claims := jwt.MapClaims{
+		"invitation": invitationID,
+		"expires":    expires.Unix(),
+	}
+
+	// Create a new JWT invite token with claims.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	// Generate token with a dedicated key, so it can't be used as an access token.
+	return token.SignedString([]byte(tokenConfig.InviteKey))
+}
+
+// ParseInviteToken func to verify an invite token and extract its metadata.
+func ParseInviteToken(inviteToken string) (*InviteTokenMetadata, error) {
+	token, err := jwt.Parse(inviteToken, func(token *jwt.Token) (interface{}, error) {
+		return []byte(tokenConfig.InviteKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Setting and checking token claims.
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invite token is not valid")
+	}
+
+	id, _ := claims["invitation"].(string)
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invite token is not valid")
+	}
+
+	expires, _ := claims["expires"].(float64)
+	if time.Now().Unix() > int64(expires) {
+		return nil, fmt.Errorf("invite token has expired")
+	}
+
+	return &InviteTokenMetadata{
+		InvitationID: invitationID,
+		Expires:      int64(expires),
+	}, nil
+}
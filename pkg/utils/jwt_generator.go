@@ -3,15 +3,24 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Figbase/api/platform/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// tokenConfig is set by ConfigureTokens on startup.
+var tokenConfig = &config.JWT{}
+
+// ConfigureTokens func for setting token signing keys and lifetimes.
+func ConfigureTokens(cfg *config.JWT) {
+	tokenConfig = cfg
+}
+
 // Tokens struct to describe tokens object.
 type Tokens struct {
 	Access  string
@@ -41,21 +50,23 @@ func GenerateNewTokens(id string, credentials []string) (*Tokens, error) {
 }
 
 func generateNewAccessToken(id string, credentials []string) (string, error) {
-	// Set secret key from .env file.
-	secret := os.Getenv("JWT_SECRET_KEY")
+	// Set secret key from config.
+	secret := tokenConfig.SecretKey
 
-	// Set expires minutes count for secret key from .env file.
-	minutesCount, _ := strconv.Atoi(os.Getenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT"))
+	// Set expires minutes count for secret key from config.
+	minutesCount := tokenConfig.SecretKeyExpireMinutes
 
 	// Create a new claims.
 	claims := jwt.MapClaims{}
 
 	// Set public claims:
 	claims["id"] = id
-	claims["expires"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
+	claims["exp"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
 	claims["app:create"] = false
 	claims["app:update"] = false
 	claims["app:delete"] = false
+	claims["user:manage"] = false
+	claims["audit:read"] = false
 
 	// Set private token credentials:
 	for _, credential := range credentials {
@@ -80,7 +91,7 @@ func generateNewRefreshToken() (string, error) {
 	hash := sha256.New()
 
 	// Create a new now date and time string with salt.
-	refresh := os.Getenv("JWT_REFRESH_KEY") + time.Now().String()
+	refresh := tokenConfig.RefreshKey + time.Now().String()
 
 	// See: https://pkg.go.dev/io#Writer.Write
 	_, err := hash.Write([]byte(refresh))
@@ -89,8 +100,8 @@ func generateNewRefreshToken() (string, error) {
 		return "", err
 	}
 
-	// Set expires hours count for refresh key from .env file.
-	hoursCount, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT"))
+	// Set expires hours count for refresh key from config.
+	hoursCount := tokenConfig.RefreshKeyExpireHours
 
 	// Set expiration time.
 	expireTime := fmt.Sprint(time.Now().Add(time.Hour * time.Duration(hoursCount)).Unix())
@@ -103,5 +114,10 @@ func generateNewRefreshToken() (string, error) {
 
 // ParseRefreshToken func for parse second argument from refresh token.
 func ParseRefreshToken(refreshToken string) (int64, error) {
-	return strconv.ParseInt(strings.Split(refreshToken, ".")[1], 0, 64)
+	_, expires, found := strings.Cut(refreshToken, ".")
+	if !found {
+		return 0, errors.New("refresh token is malformed")
+	}
+
+	return strconv.ParseInt(expires, 0, 64)
 }
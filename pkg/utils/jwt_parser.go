@@ -1,7 +1,8 @@
 package utils
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -25,31 +26,35 @@ func ExtractTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
 
 	// Setting and checking token and credentials.
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		// User ID.
-		userID, err := uuid.Parse(claims["id"].(string))
-		if err != nil {
-			return nil, err
-		}
-
-		// Expires time.
-		expires := int64(claims["expires"].(float64))
-
-		// User credentials.
-		credentials := map[string]bool{
-			"app:create": claims["app:create"].(bool),
-			"app:update": claims["app:update"].(bool),
-			"app:delete": claims["app:delete"].(bool),
-		}
-
-		return &TokenMetadata{
-			UserID:      userID,
-			Credentials: credentials,
-			Expires:     expires,
-		}, nil
+	if !ok || !token.Valid {
+		return nil, errors.New("token is not valid")
 	}
 
-	return nil, err
+	// User ID.
+	id, _ := claims["id"].(string)
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expires time, checked by the parser.
+	expires, err := claims.GetExpirationTime()
+	if err != nil || expires == nil {
+		return nil, errors.New("token has no expiration time")
+	}
+
+	// User credentials, a missing claim means the credential is not granted.
+	credentials := map[string]bool{}
+	for _, credential := range []string{"app:create", "app:update", "app:delete", "user:manage", "audit:read"} {
+		granted, _ := claims[credential].(bool)
+		credentials[credential] = granted
+	}
+
+	return &TokenMetadata{
+		UserID:      userID,
+		Credentials: credentials,
+		Expires:     expires.Unix(),
+	}, nil
 }
 
 func extractToken(c *fiber.Ctx) string {
@@ -67,7 +72,7 @@ func extractToken(c *fiber.Ctx) string {
 func verifyToken(c *fiber.Ctx) (*jwt.Token, error) {
 	tokenString := extractToken(c)
 
-	token, err := jwt.Parse(tokenString, jwtKeyFunc)
+	token, err := jwt.Parse(tokenString, JWTKeyFunc, jwt.WithExpirationRequired())
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +80,12 @@ func verifyToken(c *fiber.Ctx) (*jwt.Token, error) {
 	return token, nil
 }
 
-func jwtKeyFunc(token *jwt.Token) (interface{}, error) {
-	return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+// JWTKeyFunc func for getting the key to verify access tokens with.
+func JWTKeyFunc(token *jwt.Token) (interface{}, error) {
+	// Only HMAC signed tokens are issued.
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return []byte(tokenConfig.SecretKey), nil
 }
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScimFilterAttribute struct to describe a filterable SCIM attribute.
+type ScimFilterAttribute struct {
+	// Column is the SQL column the attribute is stored in.
+	Column string
+	// Boolean attributes are stored as an integer status (1 == true).
+	Boolean bool
+}
+
+// ScimFilterToSQL func for translating a SCIM filter expression (RFC 7644, section 3.4.2.2)
+// into a SQL where clause with arguments. Only the given attributes can be filtered on.
+func ScimFilterToSQL(filter string, attributes map[string]ScimFilterAttribute) (string, []interface{}, error) {
+	tokens, err := scimTokenize(filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &scimFilterParser{tokens: tokens, attributes: attributes}
+
+	sql, err := p.parseExpression()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return "", nil, fmt.Errorf("unexpected token %q in filter", p.tokens[p.pos])
+	}
+
+	return sql, p.args, nil
+}
+
+type scimFilterParser struct {
+	tokens     []string
+	pos        int
+	args       []interface{}
+	attributes map[string]ScimFilterAttribute
+}
+
+func (p *scimFilterParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *scimFilterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *scimFilterParser) parseExpression() (string, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		logical := strings.ToLower(p.peek())
+		if logical != "and" && logical != "or" {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseTerm()
+		if err != nil {
+			return "", err
+		}
+
+		left = fmt.Sprintf("%s %s %s", left, strings.ToUpper(logical), right)
+	}
+}
+
+func (p *scimFilterParser) parseTerm() (string, error) {
+	token := p.next()
+
+	switch strings.ToLower(token) {
+	case "":
+		return "", fmt.Errorf("unexpected end of filter")
+	case "not":
+		term, err := p.parseTerm()
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + term, nil
+	case "(":
+		expression, err := p.parseExpression()
+		if err != nil {
+			return "", err
+		}
+		if p.next() != ")" {
+			return "", fmt.Errorf("missing closing parenthesis in filter")
+		}
+		return "(" + expression + ")", nil
+	}
+
+	// Attribute comparison.
+	attribute, ok := p.attributes[strings.ToLower(token)]
+	if !ok {
+		return "", fmt.Errorf("attribute %q is not supported in filter", token)
+	}
+
+	operator := strings.ToLower(p.next())
+	if operator == "pr" {
+		return fmt.Sprintf("%s IS NOT NULL", attribute.Column), nil
+	}
+
+	value, err := scimFilterValue(p.next())
+	if err != nil {
+		return "", err
+	}
+
+	// Boolean attributes are compared with their stored status.
+	if attribute.Boolean {
+		b, ok := value.(bool)
+		if !ok || (operator != "eq" && operator != "ne") {
+			return "", fmt.Errorf("attribute %q only supports eq and ne with a boolean value", token)
+		}
+		status := 0
+		if b {
+			status = 1
+		}
+		p.args = append(p.args, status)
+		if operator == "ne" {
+			return fmt.Sprintf("%s <> ?", attribute.Column), nil
+		}
+		return fmt.Sprintf("%s = ?", attribute.Column), nil
+	}
+
+	// String comparisons are case-insensitive.
+	s := fmt.Sprint(value)
+	column := fmt.Sprintf("LOWER(%s)", attribute.Column)
+	like := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+
+	switch operator {
+	case "eq":
+		p.args = append(p.args, strings.ToLower(s))
+		return column + " = ?", nil
+	case "ne":
+		p.args = append(p.args, strings.ToLower(s))
+		return column + " <> ?", nil
+	case "co":
+		p.args = append(p.args, "%"+like+"%")
+		return column + " LIKE ?", nil
+	case "sw":
+		p.args = append(p.args, like+"%")
+		return column + " LIKE ?", nil
+	case "ew":
+		p.args = append(p.args, "%"+like)
+		return column + " LIKE ?", nil
+	case "gt", "ge", "lt", "le":
+		p.args = append(p.args, strings.ToLower(s))
+		return fmt.Sprintf("%s %s ?", column, map[string]string{"gt": ">", "ge": ">=", "lt": "<", "le": "<="}[operator]), nil
+	}
+
+	return "", fmt.Errorf("operator %q is not supported in filter", operator)
+}
+
+func scimFilterValue(token string) (interface{}, error) {
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("missing value in filter")
+	case strings.HasPrefix(token, `"`):
+		return strconv.Unquote(token)
+	case strings.EqualFold(token, "true"):
+		return true, nil
+	case strings.EqualFold(token, "false"):
+		return false, nil
+	}
+
+	if _, err := strconv.ParseFloat(token, 64); err == nil {
+		return token, nil
+	}
+
+	return nil, fmt.Errorf("value %q is not valid in filter", token)
+}
+
+func scimTokenize(filter string) ([]string, error) {
+	tokens := []string{}
+
+	for i := 0; i < len(filter); {
+		switch ch := filter[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '(' || ch == ')':
+			tokens = append(tokens, string(ch))
+			i++
+		case ch == '"':
+			// Read quoted string, keeping escaped quotes.
+			j := i + 1
+			for ; j < len(filter) && filter[j] != '"'; j++ {
+				if filter[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(filter) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(filter) && !strings.ContainsRune(" \t()\"", rune(filter[j])) {
+				j++
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
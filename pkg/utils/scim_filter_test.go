@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testScimAttributes are filterable attributes like the ones of SCIM users.
+var testScimAttributes = map[string]ScimFilterAttribute{
+	"username":       {Column: "users.email"},
+	"name.givenname": {Column: "users.first_name"},
+	"active":         {Column: "users.user_status", Boolean: true},
+}
+
+func TestScimFilterToSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		where  string
+		args   []interface{}
+	}{
+		{"eq", `userName eq "Ada@Example.com"`, "LOWER(users.email) = ?", []interface{}{"ada@example.com"}},
+		{"ne", `userName ne "ada@example.com"`, "LOWER(users.email) <> ?", []interface{}{"ada@example.com"}},
+		{"co", `userName co "ada"`, "LOWER(users.email) LIKE ?", []interface{}{"%ada%"}},
+		{"sw", `userName sw "ada"`, "LOWER(users.email) LIKE ?", []interface{}{"ada%"}},
+		{"ew", `userName ew "@example.com"`, "LOWER(users.email) LIKE ?", []interface{}{"%@example.com"}},
+		{"gt", `name.givenName gt "b"`, "LOWER(users.first_name) > ?", []interface{}{"b"}},
+		{"le", `name.givenName le "b"`, "LOWER(users.first_name) <= ?", []interface{}{"b"}},
+		{"pr", `name.givenName pr`, "users.first_name IS NOT NULL", nil},
+		{"number", `name.givenName eq 42`, "LOWER(users.first_name) = ?", []interface{}{"42"}},
+		{"case-insensitive keywords", `USERNAME EQ "ada" AND Active Eq TRUE`, "LOWER(users.email) = ? AND users.user_status = ?", []interface{}{"ada", 1}},
+		{"boolean eq", `active eq false`, "users.user_status = ?", []interface{}{0}},
+		{"boolean ne", `active ne true`, "users.user_status <> ?", []interface{}{1}},
+		{"like wildcards escaped", `userName co "a%_\\b"`, "LOWER(users.email) LIKE ?", []interface{}{`%a\%\_\\b%`}},
+		{"quoted parenthesis", `userName eq "a (b)"`, "LOWER(users.email) = ?", []interface{}{"a (b)"}},
+		{"escaped quote", `userName eq "a\"b"`, "LOWER(users.email) = ?", []interface{}{`a"b`}},
+		{
+			"or", `userName eq "a" or userName eq "b"`,
+			"LOWER(users.email) = ? OR LOWER(users.email) = ?", []interface{}{"a", "b"},
+		},
+		{
+			"grouping", `active eq true and (userName sw "a" or not (userName sw "b"))`,
+			"users.user_status = ? AND (LOWER(users.email) LIKE ? OR NOT (LOWER(users.email) LIKE ?))", []interface{}{1, "a%", "b%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := ScimFilterToSQL(tt.filter, testScimAttributes)
+			if err != nil {
+				t.Fatalf("ScimFilterToSQL(%q): %v", tt.filter, err)
+			}
+			if where != tt.where {
+				t.Errorf("where %q, want %q", where, tt.where)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestScimFilterToSQLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{"empty", ``},
+		{"unknown attribute", `password eq "secret"`},
+		{"column injection", `users.email eq "a"`},
+		{"unknown operator", `userName like "a"`},
+		{"missing value", `userName eq`},
+		{"unquoted string", `userName eq ada`},
+		{"unterminated string", `userName eq "ada`},
+		{"missing closing parenthesis", `(userName eq "a"`},
+		{"unexpected closing parenthesis", `userName eq "a")`},
+		{"trailing logical operator", `userName eq "a" and`},
+		{"missing logical operator", `userName eq "a" userName eq "b"`},
+		{"boolean with string", `active eq "true"`},
+		{"boolean with co", `active co true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if where, args, err := ScimFilterToSQL(tt.filter, testScimAttributes); err == nil {
+				t.Errorf("ScimFilterToSQL(%q) = %q, %v, want an error", tt.filter, where, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken func for a making SHA256 hex digest of a token, to store it safely.
+func HashToken(t string) string {
+	hash := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateRandomToken func for generate a new random hex token.
+func GenerateRandomToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
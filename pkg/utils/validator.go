@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
@@ -10,6 +13,15 @@ func NewValidator() *validator.Validate {
 	// Create a new validator for a Book model.
 	validate := validator.New()
 
+	// Name fields by JSON keys, like errors of the OpenAPI validation.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
 	// Custom validation for uuid.UUID fields.
 	_ = validate.RegisterValidation("uuid", func(fl validator.FieldLevel) bool {
 		field := fl.Field().String()
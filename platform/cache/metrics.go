@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// poolStatsCollector struct to export connection pool stats of the Redis client.
+type poolStatsCollector struct {
+	client redis.UniversalClient
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// registerMetrics func for exporting Redis pool stats on /metrics.
+func registerMetrics(client redis.UniversalClient) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("figbase_redis_pool_"+name, help, nil, nil)
+	}
+
+	return metrics.Registry.Register(&poolStatsCollector{
+		client:     client,
+		hits:       desc("hits_total", "Times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Times a free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "Times a wait for a connection timed out."),
+		totalConns: desc("total_connections", "Connections in the pool."),
+		idleConns:  desc("idle_connections", "Idle connections in the pool."),
+		staleConns: desc("stale_connections_total", "Stale connections removed from the pool."),
+	})
+}
+
+// Describe method to send descriptors of the pool stats.
+func (p *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.hits
+	ch <- p.misses
+	ch <- p.timeouts
+	ch <- p.totalConns
+	ch <- p.idleConns
+	ch <- p.staleConns
+}
+
+// Collect method to send current pool stats.
+func (p *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := p.client.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(p.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(p.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(p.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(p.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(p.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(p.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
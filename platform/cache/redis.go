@@ -1,38 +1,99 @@
 package cache
 
 import (
-	// "fmt"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"os"
-	"strconv"
 
+	"github.com/Figbase/api/platform/config"
 	"github.com/redis/go-redis/v9"
 )
 
-// RedisConnection func for connect to Redis server.
-func RedisConnection() (*redis.Client, error) {
-	// Define Redis database number.
-	dbNumber, _ := strconv.Atoi(os.Getenv("REDIS_DB_NUMBER"))
+// NewClient func for creating the shared Redis client and checking it with a ping.
+// Standalone, Sentinel and Cluster modes share the redis.UniversalClient interface.
+func NewClient(cfg *config.Redis) (redis.UniversalClient, error) {
+	// Set Redis options.
+	options := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		MasterName:       cfg.MasterName,
+		SentinelPassword: cfg.SentinelPassword,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		DB:               cfg.DBNumber,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolTimeout:      cfg.PoolTimeout,
+	}
+	if cfg.Mode == "standalone" || len(options.Addrs) == 0 {
+		options.Addrs = []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+	}
 
-	// Set default Redis host if REDIS_HOST is empty.
-	redisHost := os.Getenv("REDIS_HOST")
-	if redisHost == "" {
-		redisHost = "localhost" // or "127.0.0.1"
+	// Set TLS, if enabled.
+	if cfg.TLS {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
 	}
 
-	// // URL for Redis connection.
-	// redisConnURL := fmt.Sprintf(
-	// 	"%s:%s",
-	// 	redisHost,
-	// 	os.Getenv("REDIS_PORT"),
-	// )
+	// Create client for the configured mode.
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case "sentinel":
+		client = redis.NewFailoverClient(options.Failover())
+	case "cluster":
+		client = redis.NewClusterClient(options.Cluster())
+	default:
+		client = redis.NewClient(options.Simple())
+	}
 
-	// Set Redis options.
-	options := &redis.Options{
-		Addr: "cache:6379",
-		// Addr:     redisConnURL,
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       dbNumber,
+	// Trace commands.
+	client.AddHook(tracingHook{})
+
+	// Check connection, fail fast on startup.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout+cfg.ReadTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis ping: %w", err)
+	}
+
+	// Export pool stats.
+	if err := registerMetrics(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// newTLSConfig func for building TLS settings of the Redis connection.
+func newTLSConfig(cfg *config.Redis) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.TLSSkipVerify,
+	}
+
+	// Trust a custom CA, if given.
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("redis tls ca: no certificates found")
+		}
+		tlsConfig.RootCAs = pool
 	}
 
-	return redis.NewClient(options), nil
+	return tlsConfig, nil
 }
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/Figbase/api/platform/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook struct to describe a go-redis hook with a client span per command.
+// Only command names are recorded, arguments hold tokens.
+type tracingHook struct{}
+
+// DialHook method to pass dials through.
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook method to trace a single command.
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, "redis."+cmd.Name(), cmd.Name())
+		defer span.End()
+
+		err := next(ctx, cmd)
+		endSpan(span, err)
+
+		return err
+	}
+}
+
+// ProcessPipelineHook method to trace a pipeline as one span.
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startSpan(ctx, "redis.pipeline", "pipeline")
+		defer span.End()
+
+		err := next(ctx, cmds)
+		endSpan(span, err)
+
+		return err
+	}
+}
+
+// startSpan func for starting a Redis client span.
+func startSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperation(operation)),
+	)
+}
+
+// endSpan func for recording a command error, a missing key is not an error.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
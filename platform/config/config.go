@@ -0,0 +1,391 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Config struct to describe all application settings.
+type Config struct {
+	App         App         `yaml:"app" toml:"app"`
+	Server      Server      `yaml:"server" toml:"server"`
+	Database    Database    `yaml:"database" toml:"database"`
+	Redis       Redis       `yaml:"redis" toml:"redis"`
+	JWT         JWT         `yaml:"jwt" toml:"jwt"`
+	Mailer      Mailer      `yaml:"mailer" toml:"mailer"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+	Log         Log         `yaml:"log" toml:"log"`
+	RateLimit   RateLimit   `yaml:"rate_limit" toml:"rate_limit"`
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+	CORS        CORS        `yaml:"cors" toml:"cors"`
+	Session     Session     `yaml:"session" toml:"session"`
+}
+
+// App struct to describe business logic settings.
+type App struct {
+	BaseURL                string `yaml:"base_url" toml:"base_url" env:"APP_BASE_URL" validate:"omitempty,url"`
+	EmailChangeExpireHours int    `yaml:"email_change_expire_hours" toml:"email_change_expire_hours" env:"EMAIL_CHANGE_EXPIRE_HOURS_COUNT" validate:"min=1"`
+	DataExportExpireHours  int    `yaml:"data_export_expire_hours" toml:"data_export_expire_hours" env:"DATA_EXPORT_EXPIRE_HOURS_COUNT" validate:"min=1"`
+}
+
+// Server struct to describe HTTP server settings.
+type Server struct {
+	Address         string        `yaml:"address" toml:"address" env:"SERVER_ADDRESS" validate:"required"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" validate:"min=0"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" validate:"min=0"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" validate:"min=0"`
+	BodyLimit       int           `yaml:"body_limit" toml:"body_limit" env:"SERVER_BODY_LIMIT" validate:"min=1"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" validate:"min=0"`
+
+	// ShutdownDelay keeps serving after /readyz turns unready, before connections are drained.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" validate:"min=0"`
+
+	// ProxyHeader holds the client IP set by a load balancer, like X-Forwarded-For.
+	// It is only trusted from TrustedProxies, if any are given.
+	ProxyHeader    string   `yaml:"proxy_header" toml:"proxy_header" env:"SERVER_PROXY_HEADER"`
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+
+	// ValidateResponses checks responses against the OpenAPI document too, for tests and staging.
+	// A response which doesn't match it is logged and replaced by a 500 error.
+	ValidateResponses bool `yaml:"validate_responses" toml:"validate_responses" env:"SERVER_VALIDATE_RESPONSES"`
+
+	// HSTSMaxAge tells browsers to use HTTPS only for this long, sent on HTTPS requests. Zero disables it.
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age" env:"SERVER_HSTS_MAX_AGE" validate:"min=0"`
+}
+
+// Database struct to describe PostgreSQL connection settings.
+type Database struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST" validate:"required"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT" validate:"min=1,max=65535"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER" validate:"required"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" validate:"required"`
+	SSLMode  string `yaml:"ssl_mode" toml:"ssl_mode" env:"DB_SSL_MODE" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	TimeZone string `yaml:"time_zone" toml:"time_zone" env:"DB_TIME_ZONE" validate:"required,timezone"`
+
+	// MigrateOnStart applies pending migrations when the server starts.
+	MigrateOnStart bool `yaml:"migrate_on_start" toml:"migrate_on_start" env:"DB_MIGRATE_ON_START"`
+}
+
+// Redis struct to describe Redis connection settings.
+// Mode "standalone" uses Host and Port, "sentinel" and "cluster" use Addrs.
+type Redis struct {
+	Mode             string        `yaml:"mode" toml:"mode" env:"REDIS_MODE" validate:"oneof=standalone sentinel cluster"`
+	Host             string        `yaml:"host" toml:"host" env:"REDIS_HOST" validate:"required_if=Mode standalone"`
+	Port             int           `yaml:"port" toml:"port" env:"REDIS_PORT" validate:"min=1,max=65535"`
+	Addrs            []string      `yaml:"addrs" toml:"addrs" env:"REDIS_ADDRS" validate:"required_unless=Mode standalone,dive,hostname_port"`
+	MasterName       string        `yaml:"master_name" toml:"master_name" env:"REDIS_SENTINEL_MASTER" validate:"required_if=Mode sentinel"`
+	SentinelPassword string        `yaml:"sentinel_password" toml:"sentinel_password" env:"REDIS_SENTINEL_PASSWORD"`
+	Username         string        `yaml:"username" toml:"username" env:"REDIS_USERNAME"`
+	Password         string        `yaml:"password" toml:"password" env:"REDIS_PASSWORD"`
+	DBNumber         int           `yaml:"db_number" toml:"db_number" env:"REDIS_DB_NUMBER" validate:"min=0,max=15"`
+	PoolSize         int           `yaml:"pool_size" toml:"pool_size" env:"REDIS_POOL_SIZE" validate:"min=1"`
+	MinIdleConns     int           `yaml:"min_idle_conns" toml:"min_idle_conns" env:"REDIS_MIN_IDLE_CONNS" validate:"min=0"`
+	DialTimeout      time.Duration `yaml:"dial_timeout" toml:"dial_timeout" env:"REDIS_DIAL_TIMEOUT" validate:"min=0"`
+	ReadTimeout      time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"REDIS_READ_TIMEOUT" validate:"min=0"`
+	WriteTimeout     time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"REDIS_WRITE_TIMEOUT" validate:"min=0"`
+	PoolTimeout      time.Duration `yaml:"pool_timeout" toml:"pool_timeout" env:"REDIS_POOL_TIMEOUT" validate:"min=0"`
+	TLS              bool          `yaml:"tls" toml:"tls" env:"REDIS_TLS"`
+	TLSServerName    string        `yaml:"tls_server_name" toml:"tls_server_name" env:"REDIS_TLS_SERVER_NAME"`
+	TLSCAFile        string        `yaml:"tls_ca_file" toml:"tls_ca_file" env:"REDIS_TLS_CA_FILE"`
+	TLSSkipVerify    bool          `yaml:"tls_skip_verify" toml:"tls_skip_verify" env:"REDIS_TLS_SKIP_VERIFY"`
+}
+
+// JWT struct to describe token signing keys and lifetimes.
+type JWT struct {
+	SecretKey              string `yaml:"secret_key" toml:"secret_key" env:"JWT_SECRET_KEY" validate:"required"`
+	SecretKeyExpireMinutes int    `yaml:"secret_key_expire_minutes" toml:"secret_key_expire_minutes" env:"JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT" validate:"min=1"`
+	RefreshKey             string `yaml:"refresh_key" toml:"refresh_key" env:"JWT_REFRESH_KEY" validate:"required"`
+	RefreshKeyExpireHours  int    `yaml:"refresh_key_expire_hours" toml:"refresh_key_expire_hours" env:"JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT" validate:"min=1"`
+	InviteKey              string `yaml:"invite_key" toml:"invite_key" env:"JWT_INVITE_KEY" validate:"required,nefield=SecretKey"`
+	InviteExpireHours      int    `yaml:"invite_expire_hours" toml:"invite_expire_hours" env:"INVITE_EXPIRE_HOURS_COUNT" validate:"min=1"`
+}
+
+// Mailer struct to describe SMTP settings, empty host logs emails instead of sending.
+type Mailer struct {
+	Host     string `yaml:"host" toml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"SMTP_PORT" validate:"min=1,max=65535"`
+	Username string `yaml:"username" toml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" toml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" toml:"from" env:"SMTP_FROM" validate:"required,email"`
+}
+
+// Tracing struct to describe OpenTelemetry trace export.
+// Exporter "otlp" sends spans over OTLP/HTTP to Endpoint, "stdout" prints them, "none" disables tracing.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"OTEL_TRACES_EXPORTER" validate:"oneof=none stdout otlp"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" validate:"required_if=Exporter otlp"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME" validate:"required"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"OTEL_TRACES_SAMPLE_RATIO" validate:"min=0,max=1"`
+}
+
+// Log struct to describe structured logging.
+// Levels overrides Level per component, e.g. LOG_LEVELS=database=debug,http=warn.
+type Log struct {
+	Level  string            `yaml:"level" toml:"level" env:"LOG_LEVEL" validate:"oneof=debug info warn error"`
+	Format string            `yaml:"format" toml:"format" env:"LOG_FORMAT" validate:"oneof=json text"`
+	Levels map[string]string `yaml:"levels" toml:"levels" env:"LOG_LEVELS" validate:"dive,keys,oneof=app http database mailer,endkeys,oneof=debug info warn error"`
+}
+
+// RateLimit struct to describe request limits per policy, given as "<requests>/<period>", e.g. "10/1m",
+// or "off". Policies set by RATE_LIMIT_POLICIES replace only the named defaults.
+// FailOpen lets requests through while Redis is down.
+type RateLimit struct {
+	Enabled  bool              `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	FailOpen bool              `yaml:"fail_open" toml:"fail_open" env:"RATE_LIMIT_FAIL_OPEN"`
+	Policies map[string]string `yaml:"policies" toml:"policies" env:"RATE_LIMIT_POLICIES" validate:"dive,keys,oneof=signin signin_email signup email_confirm token_renew private scim,endkeys,rate"`
+}
+
+// Idempotency struct to describe how responses of requests with an Idempotency-Key are kept.
+// Responses are replayed for TTL, a request in flight holds its key for LockTimeout at most.
+type Idempotency struct {
+	Enabled     bool          `yaml:"enabled" toml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	TTL         time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL" validate:"min=1s"`
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" validate:"min=1s"`
+}
+
+// CORS struct to describe the browser origins allowed to call the API, like https://app.figbase.co.
+// Origins are listed explicitly per environment, none are allowed by default.
+// AllowCredentials lets those origins send cookies, needed by the cookie session mode.
+type CORS struct {
+	AllowOrigins     []string      `yaml:"allow_origins" toml:"allow_origins" env:"CORS_ALLOW_ORIGINS" validate:"dive,url"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE" validate:"min=0"`
+}
+
+// Session struct to describe how browser clients keep their session.
+// Mode "header" returns tokens in JSON bodies only. Mode "cookie" keeps the refresh token in an
+// HttpOnly cookie instead, and requests sending it must repeat the CSRF cookie in the X-CSRF-Token header.
+type Session struct {
+	Mode           string `yaml:"mode" toml:"mode" env:"SESSION_MODE" validate:"oneof=header cookie"`
+	CookieDomain   string `yaml:"cookie_domain" toml:"cookie_domain" env:"SESSION_COOKIE_DOMAIN"`
+	CookieSecure   bool   `yaml:"cookie_secure" toml:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieSameSite string `yaml:"cookie_same_site" toml:"cookie_same_site" env:"SESSION_COOKIE_SAME_SITE" validate:"oneof=Strict Lax None"`
+}
+
+// Default func for getting config with default values.
+func Default() *Config {
+	return &Config{
+		App: App{
+			EmailChangeExpireHours: 24,
+			DataExportExpireHours:  168,
+		},
+		Server: Server{
+			Address:         ":3000",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			BodyLimit:       4 * 1024 * 1024,
+			ShutdownTimeout: 20 * time.Second,
+			HSTSMaxAge:      180 * 24 * time.Hour,
+		},
+		Database: Database{
+			Host:           "db",
+			Port:           5432,
+			SSLMode:        "disable",
+			TimeZone:       "UTC",
+			MigrateOnStart: true,
+		},
+		Redis: Redis{
+			Mode:         "standalone",
+			Host:         "cache",
+			Port:         6379,
+			PoolSize:     10,
+			MinIdleConns: 2,
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+			PoolTimeout:  4 * time.Second,
+		},
+		JWT: JWT{
+			InviteExpireHours: 72,
+		},
+		Mailer: Mailer{
+			Port: 587,
+			From: "no-reply@figbase.co",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "figbase-api",
+			SampleRatio: 1,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		RateLimit: RateLimit{
+			Enabled:  true,
+			FailOpen: true,
+			Policies: map[string]string{
+				"signin":        "20/1m",
+				"signin_email":  "5/1m",
+				"signup":        "10/1h",
+				"email_confirm": "10/1m",
+				"token_renew":   "30/1m",
+				"private":       "600/1m",
+				"scim":          "1200/1m",
+			},
+		},
+		Idempotency: Idempotency{
+			Enabled:     true,
+			TTL:         24 * time.Hour,
+			LockTimeout: time.Minute,
+		},
+		CORS: CORS{
+			MaxAge: 10 * time.Minute,
+		},
+		Session: Session{
+			Mode:           "header",
+			CookieSecure:   true,
+			CookieSameSite: "Strict",
+		},
+	}
+}
+
+// Load func for loading config from defaults, an optional file (CONFIG_FILE) and
+// environment variables, in this order. Any variable can be read from a file by
+// setting <NAME>_FILE instead, e.g. DB_PASSWORD_FILE=/run/secrets/db_password.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	// Read optional YAML or TOML file.
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Override with environment variables.
+	if err := loadEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	// Fail fast on invalid settings.
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate func for checking all settings, errors name the environment variable to fix.
+func (cfg *Config) Validate() error {
+	validate := validator.New()
+
+	// Rates are given as "<requests>/<period>".
+	validate.RegisterValidation("rate", func(fl validator.FieldLevel) bool {
+		_, _, err := ParseRate(fl.Field().String())
+		return err == nil
+	})
+
+	// Report fields by their environment variable.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if env := field.Tag.Get("env"); env != "" {
+			return env
+		}
+		return field.Name
+	})
+
+	err := validate.Struct(cfg)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	messages := []string{}
+	for _, fe := range fieldErrors {
+		switch fe.Tag() {
+		case "required", "required_if", "required_unless":
+			messages = append(messages, fmt.Sprintf("%s is required", fe.Field()))
+		case "min", "max":
+			messages = append(messages, fmt.Sprintf("%s must be %s %s, got %v", fe.Field(), map[string]string{"min": "at least", "max": "at most"}[fe.Tag()], fe.Param(), fe.Value()))
+		case "oneof":
+			messages = append(messages, fmt.Sprintf("%s must be one of [%s], got %q", fe.Field(), fe.Param(), fe.Value()))
+		case "nefield":
+			messages = append(messages, fmt.Sprintf("%s must differ from %s", fe.Field(), envName(reflect.TypeOf(*cfg), fe.Param())))
+		default:
+			messages = append(messages, fmt.Sprintf("%s must be a valid %s, got %q", fe.Field(), fe.Tag(), fe.Value()))
+		}
+	}
+
+	return fmt.Errorf("invalid config:\n  %s", strings.Join(messages, "\n  "))
+}
+
+// ParseRate func for parsing a rate like "10/1m" into requests and period, "off" gives zero.
+func ParseRate(rate string) (int, time.Duration, error) {
+	if rate == "off" {
+		return 0, 0, nil
+	}
+
+	count, period, ok := strings.Cut(rate, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("rate %q must look like 10/1m", rate)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		return 0, 0, fmt.Errorf("rate %q must allow at least 1 request", rate)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("rate %q must have a positive period like 1m", rate)
+	}
+
+	return n, d, nil
+}
+
+// loadFile func for reading config file by its extension.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file: unsupported format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// envName func for finding environment variable of the named field in config sections.
+func envName(t reflect.Type, field string) string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			if name := envName(f.Type, field); name != field {
+				return name
+			}
+		}
+		if f.Name == field && f.Tag.Get("env") != "" {
+			return f.Tag.Get("env")
+		}
+	}
+
+	return field
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequired func for setting the variables without a usable default, so Load can succeed.
+func setRequired(t *testing.T) {
+	t.Helper()
+
+	for name, value := range map[string]string{
+		"CONFIG_FILE":     "",
+		"DB_USER":         "figbase",
+		"DB_NAME":         "figbase",
+		"JWT_SECRET_KEY":  "secret",
+		"JWT_REFRESH_KEY": "refresh",
+		"JWT_INVITE_KEY":  "invite",
+
+		"JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT": "15",
+		"JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT":  "720",
+	} {
+		t.Setenv(name, value)
+	}
+}
+
+// writeFile func for writing a file into a temporary directory and returning its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  address: \":4000\"\n  read_timeout: 15s\ndatabase:\n  password: from-file\n")
+	secret := writeFile(t, "db_password", "from-secret\n")
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		address  string
+		password string
+	}{
+		{
+			name:     "defaults",
+			env:      map[string]string{},
+			address:  ":3000",
+			password: "",
+		},
+		{
+			name:     "file overrides defaults",
+			env:      map[string]string{"CONFIG_FILE": file},
+			address:  ":4000",
+			password: "from-file",
+		},
+		{
+			name:     "env overrides file",
+			env:      map[string]string{"CONFIG_FILE": file, "SERVER_ADDRESS": ":5000", "DB_PASSWORD": "from-env"},
+			address:  ":5000",
+			password: "from-env",
+		},
+		{
+			name:     "_FILE overrides env",
+			env:      map[string]string{"CONFIG_FILE": file, "DB_PASSWORD": "from-env", "DB_PASSWORD_FILE": secret},
+			address:  ":4000",
+			password: "from-secret",
+		},
+		{
+			name:     "empty env keeps file",
+			env:      map[string]string{"CONFIG_FILE": file, "SERVER_ADDRESS": ""},
+			address:  ":4000",
+			password: "from-file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequired(t)
+			for _, name := range []string{"SERVER_ADDRESS", "DB_PASSWORD", "DB_PASSWORD_FILE"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Address != tt.address {
+				t.Errorf("address %q, want %q", cfg.Server.Address, tt.address)
+			}
+			if cfg.Database.Password != tt.password {
+				t.Errorf("password %q, want %q", cfg.Database.Password, tt.password)
+			}
+		})
+	}
+}
+
+func TestLoadFileDurations(t *testing.T) {
+	setRequired(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "server:\n  read_timeout: 15s\nidempotency:\n  ttl: 2h\n"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.ReadTimeout != 15*time.Second || cfg.Idempotency.TTL != 2*time.Hour {
+		t.Errorf("read timeout %v, TTL %v", cfg.Server.ReadTimeout, cfg.Idempotency.TTL)
+	}
+}
+
+func TestLoadEnvTypes(t *testing.T) {
+	setRequired(t)
+	for name, value := range map[string]string{
+		"SERVER_READ_TIMEOUT":      "250ms",
+		"SERVER_BODY_LIMIT":        "1024",
+		"OTEL_TRACES_SAMPLE_RATIO": "0.5",
+		"DB_MIGRATE_ON_START":      "false",
+		"CORS_ALLOW_ORIGINS":       " https://app.figbase.co, ,https://figbase.co ",
+		"LOG_LEVELS":               "http=warn, database=debug",
+	} {
+		t.Setenv(name, value)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.ReadTimeout != 250*time.Millisecond {
+		t.Errorf("read timeout %v, want 250ms", cfg.Server.ReadTimeout)
+	}
+	if cfg.Server.BodyLimit != 1024 {
+		t.Errorf("body limit %d, want 1024", cfg.Server.BodyLimit)
+	}
+	if cfg.Tracing.SampleRatio != 0.5 {
+		t.Errorf("sample ratio %v, want 0.5", cfg.Tracing.SampleRatio)
+	}
+	if cfg.Database.MigrateOnStart {
+		t.Error("migrate on start is true, want false")
+	}
+	if want := []string{"https://app.figbase.co", "https://figbase.co"}; !reflect.DeepEqual(cfg.CORS.AllowOrigins, want) {
+		t.Errorf("origins %q, want %q", cfg.CORS.AllowOrigins, want)
+	}
+	if want := map[string]string{"http": "warn", "database": "debug"}; !reflect.DeepEqual(cfg.Log.Levels, want) {
+		t.Errorf("log levels %v, want %v", cfg.Log.Levels, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  string
+	}{
+		{"missing database user", map[string]string{"DB_USER": ""}, "DB_USER is required"},
+		{"missing secret key", map[string]string{"JWT_SECRET_KEY": ""}, "JWT_SECRET_KEY is required"},
+		{"missing token lifetime", map[string]string{"JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT": ""}, "JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT must be at least 1, got 0"},
+		{"invite key same as secret key", map[string]string{"JWT_INVITE_KEY": "secret"}, "JWT_INVITE_KEY must differ from JWT_SECRET_KEY"},
+		{"invalid duration", map[string]string{"SERVER_READ_TIMEOUT": "10"}, "SERVER_READ_TIMEOUT must be a duration like 5s"},
+		{"invalid integer", map[string]string{"DB_PORT": "five"}, "DB_PORT must be an integer"},
+		{"invalid boolean", map[string]string{"REDIS_TLS": "maybe"}, "REDIS_TLS must be a boolean"},
+		{"invalid map", map[string]string{"LOG_LEVELS": "http"}, "LOG_LEVELS must be a list like key=value"},
+		{"out of range", map[string]string{"DB_PORT": "70000"}, "DB_PORT must be at most 65535"},
+		{"not one of", map[string]string{"LOG_LEVEL": "loud"}, "LOG_LEVEL must be one of"},
+		{"invalid rate", map[string]string{"RATE_LIMIT_POLICIES": "signin=lots"}, "RATE_LIMIT_POLICIES"},
+		{"missing _FILE", map[string]string{"DB_PASSWORD_FILE": "/nonexistent/db_password"}, "DB_PASSWORD_FILE"},
+		{"unsupported file format", map[string]string{"CONFIG_FILE": "config.json"}, "config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequired(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadEnv func for setting struct fields from environment variables named in their env tag.
+func loadEnv(v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		// Walk into nested config sections.
+		if value.Kind() == reflect.Struct {
+			if err := loadEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw, found, err := lookupEnv(name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		if err := setField(value, raw); err != nil {
+			return fmt.Errorf("invalid config:\n  %s %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv func for getting variable value, or content of the file named by <NAME>_FILE.
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid config:\n  %s_FILE: %w", name, err)
+		}
+		return strings.TrimSpace(string(data)), true, nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	return value, ok && value != "", nil
+}
+
+// setField func for parsing raw value into the field by its kind.
+func setField(value reflect.Value, raw string) error {
+	// Durations are given as "5s", "250ms" or "1m".
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 5s, got %q", raw)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", raw)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be a boolean, got %q", raw)
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		// Lists are given comma separated.
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		// Maps are given as comma separated key=value pairs, merged into defaults.
+		if value.IsNil() {
+			value.Set(reflect.ValueOf(map[string]string{}))
+		}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("must be a list like key=value,key=value, got %q", raw)
+			}
+			value.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(val)))
+		}
+	default:
+		return fmt.Errorf("has unsupported type %s", value.Type())
+	}
+
+	return nil
+}
@@ -1,14 +1,14 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
 
-	"github.com/Figbase/api/app/models"
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type Dbinstance struct {
@@ -17,31 +17,70 @@ type Dbinstance struct {
 
 var DB Dbinstance
 
-func ConnectDb() {
+// ConnectDb func for opening the shared database connection pool.
+func ConnectDb(cfg *config.Database) error {
 	dsn := fmt.Sprintf(
-		"host=db user=%s password=%s dbname=%s port=5432 sslmode=disable TimeZone=Asia/Shanghai",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		cfg.Host,
+		cfg.User,
+		cfg.Password,
+		cfg.Name,
+		cfg.Port,
+		cfg.SSLMode,
+		cfg.TimeZone,
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         newGormLogger(),
+		TranslateError: true,
 	})
-
 	if err != nil {
-		log.Fatal("Failed to connect to database. \n", err)
-		os.Exit(2)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("connected")
-	db.Logger = logger.Default.LogMode(logger.Info)
+	logging.For("database").Info("connected", "host", cfg.Host, "name", cfg.Name)
 
-	log.Println("running migrations")
-	db.AutoMigrate(&models.User{})
+	// Time queries and export pool stats.
+	if err := registerMetrics(db); err != nil {
+		return fmt.Errorf("failed to register database metrics: %w", err)
+	}
+
+	// Trace queries.
+	if err := registerTracing(db); err != nil {
+		return fmt.Errorf("failed to register database tracing: %w", err)
+	}
 
 	DB = Dbinstance{
 		Db: db,
 	}
 
+	return nil
+}
+
+// Close func for closing the database connection pool.
+func Close() error {
+	if DB.Db == nil {
+		return nil
+	}
+
+	sqlDB, err := DB.Db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
+
+// Ping func for checking the database connection.
+func Ping(ctx context.Context) error {
+	if DB.Db == nil {
+		return errors.New("database is not connected")
+	}
+
+	sqlDB, err := DB.Db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
 }
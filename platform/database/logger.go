@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Figbase/api/platform/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which queries are logged as warnings.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger struct to write GORM logs to the "database" component.
+// Statements are logged with placeholders only, bound values like emails never reach the logs.
+type gormLogger struct {
+	log *slog.Logger
+}
+
+// newGormLogger func for creating the GORM logger, levels come from the log config.
+func newGormLogger() gormLogger {
+	return gormLogger{log: logging.For("database")}
+}
+
+// LogMode method to satisfy logger.Interface, the level is set per component in config.
+func (l gormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+// Info method to log a GORM message at info level.
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Warn method to log a GORM message at warn level.
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Error method to log a GORM message at error level.
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Trace method to log a statement: failed at error, slow at warn, any other at debug.
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case elapsed > slowQueryThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !l.log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter method to drop bound values, so statements are logged with $1, $2 placeholders.
+func (l gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Figbase/api/platform/metrics"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// metricsStartKey is the statement setting holding the query start time.
+const metricsStartKey = "metrics:start"
+
+// registerMetrics func for timing every GORM operation and exporting pool stats.
+func registerMetrics(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			start, ok := tx.InstanceGet(metricsStartKey)
+			if !ok {
+				return
+			}
+
+			table := tx.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+			metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start.(time.Time)).Seconds())
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				metrics.DBQueryErrors.WithLabelValues(operation, table).Inc()
+			}
+		}
+	}
+
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", before),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", before),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", before),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", before),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	// Connection pool stats of database/sql.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return metrics.Registry.Register(collectors.NewDBStatsCollector(sqlDB, "postgres"))
+}
@@ -0,0 +1,134 @@
+// Package logging sets up structured logging with levels per component and redaction of personal data and secrets.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Figbase/api/platform/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextKey type for request values stored in the context.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// settings set by Setup, read when a component logger is created.
+var (
+	mu       sync.RWMutex
+	output   io.Writer = os.Stdout
+	format             = "json"
+	fallback           = slog.LevelInfo
+	levels             = map[string]slog.Level{}
+)
+
+// Setup func for applying log settings and writing to w.
+// The standard library logger is redirected to the "app" component.
+func Setup(cfg *config.Log, w io.Writer) {
+	mu.Lock()
+	output, format = w, cfg.Format
+	fallback = parseLevel(cfg.Level)
+	levels = map[string]slog.Level{}
+	for component, level := range cfg.Levels {
+		levels[component] = parseLevel(level)
+	}
+	mu.Unlock()
+
+	slog.SetDefault(For("app"))
+}
+
+// For func for getting the logger of a component, like "http" or "database".
+func For(component string) *slog.Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	level, ok := levels[component]
+	if !ok {
+		level = fallback
+	}
+
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	if format == "text" {
+		h = slog.NewTextHandler(output, options)
+	} else {
+		h = slog.NewJSONHandler(output, options)
+	}
+
+	return slog.New(handler{h}).With("component", component)
+}
+
+// WithRequestID func for storing the request ID in the context, logged with each record.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID func for getting the request ID from the context.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID func for storing the authenticated user ID in the context, logged with each record.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserID func for getting the authenticated user ID from the context.
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+// handler struct to add request fields from the context and redact the message.
+type handler struct {
+	slog.Handler
+}
+
+// Handle method to write the record with request ID, user ID and trace ID, if known.
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	r.Message = Redact(r.Message)
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id := UserID(ctx); id != "" {
+		r.AddAttrs(slog.String("user_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs method to keep request fields on derived loggers.
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup method to keep request fields on derived loggers.
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{h.Handler.WithGroup(name)}
+}
+
+// parseLevel func for converting a config level name, unknown names fall back to info.
+func parseLevel(name string) slog.Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
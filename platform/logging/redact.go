@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of attribute names whose values are never logged.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key", "apikey"}
+
+// Patterns of personal data and secrets inside free text, like SQL errors or email bodies.
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,})`)
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9._~+/=\-]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	paramPattern  = regexp.MustCompile(`(?i)([?&](?:token|code|key|password)=)[^&\s]+`)
+)
+
+// Redact func for masking emails, bearer tokens, JWTs and secret URL parameters in text.
+// Emails keep their domain, e.g. ***@figbase.co.
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+redacted)
+	s = paramPattern.ReplaceAllString(s, "${1}"+redacted)
+	return emailPattern.ReplaceAllString(s, "***@$1")
+}
+
+// redactAttr func for redacting an attribute before it is written, used as slog ReplaceAttr.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		// Errors often quote the values that failed.
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+
+	return a
+}
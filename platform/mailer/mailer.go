@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/Figbase/api/platform/config"
+	"github.com/Figbase/api/platform/logging"
+)
+
+// mailerConfig is set by Configure on startup.
+var mailerConfig = &config.Mailer{}
+
+// Message struct to describe an outgoing email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Configure func for setting SMTP server settings.
+func Configure(cfg *config.Mailer) {
+	mailerConfig = cfg
+}
+
+// Send func for deliver a plain text email through the SMTP server.
+func Send(msg *Message) error {
+	if mailerConfig.Host == "" {
+		// SMTP is not configured (local development), just log the message.
+		// Addresses and links with tokens are redacted like in any other record.
+		logging.For("mailer").Info("email not sent, SMTP is not configured", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+		return nil
+	}
+
+	// Build RFC 822 message.
+	body := strings.Join([]string{
+		"From: " + mailerConfig.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	// Authenticate only if credentials are given.
+	var auth smtp.Auth
+	if mailerConfig.Username != "" {
+		auth = smtp.PlainAuth("", mailerConfig.Username, mailerConfig.Password, mailerConfig.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", mailerConfig.Host, mailerConfig.Port)
+	return smtp.SendMail(addr, auth, mailerConfig.From, []string{msg.To}, []byte(body))
+}
@@ -0,0 +1,100 @@
+// Package metrics holds the Prometheus collectors of the application.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry is the registry served on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests by method, route pattern and status.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_http_requests_total",
+		Help: "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes request latency by method, route pattern and status.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "figbase_http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// HTTPInFlight counts requests being handled right now.
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "figbase_http_requests_in_flight",
+		Help: "HTTP requests being handled.",
+	})
+
+	// AuthEvents counts sign ups, sign ins, renewals and sign outs by result.
+	AuthEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_auth_events_total",
+		Help: "Authentication events by event and result.",
+	}, []string{"event", "result"})
+
+	// TokenValidationFailures counts rejected access tokens by reason.
+	TokenValidationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_token_validation_failures_total",
+		Help: "Rejected access tokens by reason.",
+	}, []string{"reason"})
+
+	// RateLimited counts requests refused by a rate limit policy.
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_rate_limited_requests_total",
+		Help: "Requests refused by rate limit policy.",
+	}, []string{"policy"})
+
+	// IdempotentRequests counts requests with an Idempotency-Key which were not handled again,
+	// by outcome: replayed, in_flight or key_reused.
+	IdempotentRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_idempotent_requests_total",
+		Help: "Requests with an idempotency key answered without the handler, by outcome.",
+	}, []string{"outcome"})
+
+	// DBQueryDuration observes GORM query latency by operation and table.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "figbase_db_query_duration_seconds",
+		Help:    "Database query latency by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	// DBQueryErrors counts failed GORM queries by operation and table, not found is not an error.
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "figbase_db_query_errors_total",
+		Help: "Failed database queries by operation and table.",
+	}, []string{"operation", "table"})
+)
+
+// Auth event results.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		AuthEvents,
+		TokenValidationFailures,
+		RateLimited,
+		IdempotentRequests,
+		DBQueryDuration,
+		DBQueryErrors,
+	)
+}
+
+// AuthEvent func for counting an authentication event with its result.
+func AuthEvent(event string, ok bool) {
+	result := ResultSuccess
+	if !ok {
+		result = ResultFailure
+	}
+	AuthEvents.WithLabelValues(event, result).Inc()
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS erasure_tombstones;
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS scim_tokens;
+DROP TABLE IF EXISTS invitations;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,139 @@
+-- Baseline of the schema created by GORM AutoMigrate.
+-- IF NOT EXISTS keeps it safe for databases created before migrations, columns are
+-- added one by one too, as tables of older AutoMigrate runs may lack newer ones.
+
+CREATE TABLE IF NOT EXISTS users (
+    id text PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    first_name text,
+    last_name text,
+    email text,
+    password_hash text,
+    user_status bigint,
+    user_role text,
+    timezone text,
+    locale text,
+    avatar_url text
+);
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS first_name text,
+    ADD COLUMN IF NOT EXISTS last_name text,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS password_hash text,
+    ADD COLUMN IF NOT EXISTS user_status bigint,
+    ADD COLUMN IF NOT EXISTS user_role text,
+    ADD COLUMN IF NOT EXISTS timezone text,
+    ADD COLUMN IF NOT EXISTS locale text,
+    ADD COLUMN IF NOT EXISTS avatar_url text;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id uuid PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    name text,
+    owner_id uuid
+);
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS owner_id uuid;
+
+CREATE TABLE IF NOT EXISTS memberships (
+    id uuid PRIMARY KEY,
+    created_at timestamptz,
+    organization_id uuid,
+    user_id uuid,
+    role text,
+    external_id text
+);
+ALTER TABLE memberships
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS organization_id uuid,
+    ADD COLUMN IF NOT EXISTS user_id uuid,
+    ADD COLUMN IF NOT EXISTS role text,
+    ADD COLUMN IF NOT EXISTS external_id text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_memberships_org_user ON memberships (organization_id, user_id);
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id uuid PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    organization_id uuid,
+    invited_by uuid,
+    email text,
+    role text,
+    status text,
+    token_hash text,
+    expires_at timestamptz,
+    accepted_at timestamptz
+);
+ALTER TABLE invitations
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS organization_id uuid,
+    ADD COLUMN IF NOT EXISTS invited_by uuid,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS role text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS token_hash text,
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS accepted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_invitations_organization_id ON invitations (organization_id);
+CREATE INDEX IF NOT EXISTS idx_invitations_email ON invitations (email);
+
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    id uuid PRIMARY KEY,
+    created_at timestamptz,
+    organization_id uuid,
+    token_hash text,
+    last_used_at timestamptz
+);
+ALTER TABLE scim_tokens
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS organization_id uuid,
+    ADD COLUMN IF NOT EXISTS token_hash text,
+    ADD COLUMN IF NOT EXISTS last_used_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_tokens_organization_id ON scim_tokens (organization_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scim_tokens_token_hash ON scim_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id uuid PRIMARY KEY,
+    created_at timestamptz,
+    user_id uuid,
+    status text,
+    error text,
+    completed_at timestamptz,
+    expires_at timestamptz,
+    archive bytea
+);
+ALTER TABLE data_exports
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id uuid,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS error text,
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz,
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS archive bytea;
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
+
+CREATE TABLE IF NOT EXISTS erasure_tombstones (
+    id uuid PRIMARY KEY,
+    user_id uuid,
+    email_hash text,
+    requested_by uuid,
+    erased_at timestamptz
+);
+ALTER TABLE erasure_tombstones
+    ADD COLUMN IF NOT EXISTS user_id uuid,
+    ADD COLUMN IF NOT EXISTS email_hash text,
+    ADD COLUMN IF NOT EXISTS requested_by uuid,
+    ADD COLUMN IF NOT EXISTS erased_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_erasure_tombstones_user_id ON erasure_tombstones (user_id);
+CREATE INDEX IF NOT EXISTS idx_erasure_tombstones_email_hash ON erasure_tombstones (email_hash);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+DROP INDEX IF EXISTS idx_users_email_lower;
+
+ALTER TABLE users
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN email DROP NOT NULL,
+    ALTER COLUMN password_hash DROP NOT NULL,
+    ALTER COLUMN user_status DROP NOT NULL,
+    ALTER COLUMN user_status DROP DEFAULT,
+    ALTER COLUMN user_role DROP NOT NULL;
+
+ALTER TABLE users ALTER COLUMN id TYPE text USING id::text;
@@ -0,0 +1,40 @@
+-- Users get a UUID primary key and a case-insensitive unique email.
+-- Stops with an error, instead of guessing, if live accounts share an email once
+-- it is trimmed and lowercased, as below.
+
+DO $$
+DECLARE
+    duplicates bigint;
+BEGIN
+    SELECT count(*) INTO duplicates
+    FROM (
+        SELECT lower(trim(email)) AS email
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(trim(email))
+        HAVING count(*) > 1
+    ) AS d;
+
+    IF duplicates > 0 THEN
+        RAISE EXCEPTION '% emails are used by more than one user, merge or delete those users first', duplicates;
+    END IF;
+END $$;
+
+ALTER TABLE users ALTER COLUMN id TYPE uuid USING id::uuid;
+
+UPDATE users SET created_at = now() WHERE created_at IS NULL;
+UPDATE users SET updated_at = created_at WHERE updated_at IS NULL;
+UPDATE users SET email = lower(trim(email));
+
+ALTER TABLE users
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN email SET NOT NULL,
+    ALTER COLUMN password_hash SET NOT NULL,
+    ALTER COLUMN user_status SET NOT NULL,
+    ALTER COLUMN user_status SET DEFAULT 1,
+    ALTER COLUMN user_role SET NOT NULL;
+
+-- Deleted accounts keep their email but don't block signing up with it.
+CREATE UNIQUE INDEX idx_users_email_lower ON users (lower(email)) WHERE deleted_at IS NULL;
+CREATE INDEX idx_users_created_at ON users (created_at);
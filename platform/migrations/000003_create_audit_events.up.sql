@@ -0,0 +1,32 @@
+-- Append-only security audit log. Each event holds the hash of the previous
+-- one (prev_hash), so a removed or changed event breaks the chain.
+
+CREATE TABLE audit_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    actor_id uuid,
+    target_id uuid,
+    action text NOT NULL,
+    outcome text NOT NULL,
+    ip text NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    metadata jsonb NOT NULL DEFAULT '{}',
+    prev_hash text NOT NULL,
+    hash text NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_actor_id ON audit_events (actor_id, id);
+CREATE INDEX idx_audit_events_target_id ON audit_events (target_id, id);
+CREATE INDEX idx_audit_events_action ON audit_events (action, id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+-- Reject changes of stored events, even by the application.
+CREATE FUNCTION audit_events_append_only() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only, % is not allowed', TG_OP;
+END $$;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
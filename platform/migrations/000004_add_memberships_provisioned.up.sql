@@ -0,0 +1,4 @@
+-- Memberships created by SCIM mark the accounts an organization manages.
+-- Existing ones are left unmarked, so SCIM can't change accounts it didn't create.
+
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS provisioned boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE erasure_tombstones ADD COLUMN IF NOT EXISTS email_hash text;
+CREATE INDEX IF NOT EXISTS idx_erasure_tombstones_email_hash ON erasure_tombstones (email_hash);
@@ -0,0 +1,5 @@
+-- Unsalted email hashes could be reversed by hashing known emails,
+-- tombstones keep only the ID of the erased user.
+
+DROP INDEX IF EXISTS idx_erasure_tombstones_email_hash;
+ALTER TABLE erasure_tombstones DROP COLUMN IF EXISTS email_hash;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_invitations_pending_email;
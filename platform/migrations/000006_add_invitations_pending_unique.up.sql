@@ -0,0 +1,17 @@
+-- One pending invitation per email and organization, so concurrent invites can't both be created.
+-- Older duplicates are revoked first, the newest one stays pending.
+
+UPDATE invitations SET status = 'revoked'
+WHERE status = 'pending' AND id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY organization_id, lower(email) ORDER BY created_at DESC, id
+        ) AS rank
+        FROM invitations
+        WHERE status = 'pending'
+    ) AS pending
+    WHERE rank > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invitations_pending_email
+    ON invitations (organization_id, lower(email)) WHERE status = 'pending';
@@ -0,0 +1,10 @@
+// Package migrations holds the versioned SQL migrations of the database.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+package migrations
+
+import "embed"
+
+// FS contains all SQL migration files, embedded into the binary.
+//
+//go:embed *.sql
+var FS embed.FS